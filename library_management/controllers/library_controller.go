@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -51,8 +52,20 @@ func (c *Controller) Start() {
 		case "8":
 			c.handleReserveBook(reader)
 		case "9":
-			c.handleSimulateConcurrentReservations(reader)
+			c.handleCancelReservation(reader)
 		case "10":
+			c.handleExtendReservation(reader)
+		case "11":
+			c.handleSimulateConcurrentReservations(reader)
+		case "12":
+			c.handleMemberHistory(reader)
+		case "13":
+			c.handleLibraryReport(reader)
+		case "14":
+			c.handleViewMember(reader)
+		case "15":
+			c.handleAlsoBorrowed(reader)
+		case "16":
 			fmt.Println("Exiting. Goodbye!")
 			return
 		default:
@@ -72,8 +85,14 @@ func printMenu() {
 	fmt.Println("6) List Available Books")
 	fmt.Println("7) List Borrowed Books by Member")
 	fmt.Println("8) Reserve Book (single request)")
-	fmt.Println("9) Simulate Concurrent Reservations")
-	fmt.Println("10) Exit")
+	fmt.Println("9) Cancel Reservation")
+	fmt.Println("10) Extend Reservation")
+	fmt.Println("11) Simulate Concurrent Reservations")
+	fmt.Println("12) Member Borrowing History")
+	fmt.Println("13) Library Statistics")
+	fmt.Println("14) View Member and Recommendations")
+	fmt.Println("15) Members Who Borrowed This Also Borrowed")
+	fmt.Println("16) Exit")
 }
 
 func (c *Controller) handleAddBook(reader *bufio.Reader) {
@@ -81,11 +100,13 @@ func (c *Controller) handleAddBook(reader *bufio.Reader) {
 	id := promptInt(reader, "Book ID: ")
 	title := promptString(reader, "Title: ")
 	author := promptString(reader, "Author: ")
+	genre := promptString(reader, "Genre: ")
 
 	book := models.Book{
 		ID:     id,
 		Title:  title,
 		Author: author,
+		Genre:  genre,
 		Status: "Available",
 	}
 	c.lib.AddBook(book)
@@ -181,6 +202,73 @@ func (c *Controller) handleReserveBook(reader *bufio.Reader) {
 	}
 }
 
+func (c *Controller) handleCancelReservation(reader *bufio.Reader) {
+	fmt.Println("--- Cancel Reservation ---")
+	bookID := promptInt(reader, "Book ID: ")
+	memberID := promptInt(reader, "Member ID: ")
+	if err := c.lib.CancelReservation(bookID, memberID); err != nil {
+		fmt.Println("Error cancelling reservation:", err)
+	} else {
+		fmt.Println("Reservation cancelled successfully.")
+	}
+}
+
+func (c *Controller) handleExtendReservation(reader *bufio.Reader) {
+	fmt.Println("--- Extend Reservation ---")
+	bookID := promptInt(reader, "Book ID: ")
+	memberID := promptInt(reader, "Member ID: ")
+	seconds := promptInt(reader, "Extend by how many seconds?: ")
+	if err := c.lib.ExtendReservation(bookID, memberID, time.Duration(seconds)*time.Second); err != nil {
+		fmt.Println("Error extending reservation:", err)
+	} else {
+		fmt.Printf("Reservation extended by %d seconds.\n", seconds)
+	}
+}
+
+func (c *Controller) handleViewMember(reader *bufio.Reader) {
+	fmt.Println("--- View Member ---")
+	memberID := promptInt(reader, "Member ID: ")
+	member, err := c.lib.GetMember(memberID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("ID: %d | Name: %s | Borrowed now: %d | Completed loans: %d\n",
+		member.ID, member.Name, len(member.BorrowedBooks), len(member.History))
+	for _, b := range member.BorrowedBooks {
+		fmt.Printf("  Borrowed: %s by %s\n", b.Title, b.Author)
+	}
+
+	recs, err := c.lib.RecommendForMember(memberID, 5)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("Recommended for this member:")
+	printRecommendations(recs)
+}
+
+func (c *Controller) handleAlsoBorrowed(reader *bufio.Reader) {
+	fmt.Println("--- Members Who Borrowed This Also Borrowed ---")
+	bookID := promptInt(reader, "Book ID: ")
+	recs, err := c.lib.AlsoBorrowed(bookID, 5)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	printRecommendations(recs)
+}
+
+func printRecommendations(recs []models.Recommendation) {
+	if len(recs) == 0 {
+		fmt.Println("  No recommendations yet.")
+		return
+	}
+	for _, r := range recs {
+		fmt.Printf("  ID: %d | Title: %s | Author: %s | Score: %d | %s\n", r.BookID, r.Title, r.Author, r.Score, r.Reason)
+	}
+}
+
 // Simulate many members simultaneously trying to reserve the same (or different) books
 func (c *Controller) handleSimulateConcurrentReservations(reader *bufio.Reader) {
 	fmt.Println("--- Simulate Concurrent Reservations ---")
@@ -238,6 +326,54 @@ func (c *Controller) handleSimulateConcurrentReservations(reader *bufio.Reader)
 	fmt.Println("Done waiting. Simulation finished.")
 }
 
+func (c *Controller) handleMemberHistory(reader *bufio.Reader) {
+	fmt.Println("--- Member Borrowing History ---")
+	memberID := promptInt(reader, "Member ID: ")
+	asJSON := promptJSON(reader)
+	history, err := c.lib.MemberHistory(memberID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if asJSON {
+		printJSON(history)
+		return
+	}
+	if len(history) == 0 {
+		fmt.Println("Member has no completed loans.")
+		return
+	}
+	for _, loan := range history {
+		returned := "-"
+		if loan.ReturnedAt != nil {
+			returned = loan.ReturnedAt.Format(time.DateTime)
+		}
+		fmt.Printf("ID: %d | Title: %s | Borrowed: %s | Returned: %s | Length: %v\n",
+			loan.BookID, loan.Title, loan.BorrowedAt.Format(time.DateTime), returned, loan.Duration().Round(time.Second))
+	}
+}
+
+func (c *Controller) handleLibraryReport(reader *bufio.Reader) {
+	fmt.Println("--- Library Statistics ---")
+	top := promptInt(reader, "How many entries per ranking? (e.g., 5): ")
+	asJSON := promptJSON(reader)
+	report := c.lib.Report(top)
+	if asJSON {
+		printJSON(report)
+		return
+	}
+	fmt.Printf("Total loans: %d (%d active)\n", report.TotalLoans, report.ActiveLoans)
+	fmt.Printf("Average loan length: %v\n", time.Duration(report.AverageLoanSeconds*float64(time.Second)).Round(time.Second))
+	fmt.Println("Most borrowed titles:")
+	for i, tc := range report.MostBorrowed {
+		fmt.Printf("  %d. %s by %s (%d loans)\n", i+1, tc.Title, tc.Author, tc.Loans)
+	}
+	fmt.Println("Most active members:")
+	for i, m := range report.ActiveMembers {
+		fmt.Printf("  %d. %s (ID %d, %d loans)\n", i+1, m.Name, m.MemberID, m.Loans)
+	}
+}
+
 // Helper prompts
 func promptString(reader *bufio.Reader, prompt string) string {
 	fmt.Print(prompt)
@@ -258,3 +394,17 @@ func promptInt(reader *bufio.Reader, prompt string) int {
 		return n
 	}
 }
+
+func promptJSON(reader *bufio.Reader) bool {
+	format := promptString(reader, "Output format (text/json) [text]: ")
+	return strings.EqualFold(format, "json")
+}
+
+func printJSON(v any) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Println("Error encoding JSON:", err)
+		return
+	}
+	fmt.Println(string(out))
+}
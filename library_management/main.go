@@ -9,6 +9,7 @@ import (
 
 func main() {
 	lib := services.NewLibrary()
+	defer lib.Close()
 	// optional: seed sample data
 	lib.SeedSampleData()
 
@@ -7,7 +7,9 @@ type Book struct {
 	ID          int       `json:"id"`
 	Title       string    `json:"title"`
 	Author      string    `json:"author"`
+	Genre       string    `json:"genre,omitempty"`
 	Status      string    `json:"status"` // "Available" or "Borrowed"
 	ReservedBy  int       `json:"reserved_by,omitempty"`
-	ReservedAt  time.Time `json:"reserved_at,omitempty"`
+	ReservedAt  time.Time `json:"reserved_at,omitempty"` // shifted forward by each extension
+	Extensions  int       `json:"extensions,omitempty"`  // times the current reservation was extended
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Loan records a single borrowing of a book by a member.
+type Loan struct {
+	BookID     int        `json:"book_id"`
+	Title      string     `json:"title"`
+	Author     string     `json:"author"`
+	MemberID   int        `json:"member_id"`
+	BorrowedAt time.Time  `json:"borrowed_at"`
+	ReturnedAt *time.Time `json:"returned_at,omitempty"` // nil while the loan is active
+}
+
+// Duration returns how long the book was out, or zero if it hasn't been returned.
+func (l Loan) Duration() time.Duration {
+	if l.ReturnedAt == nil {
+		return 0
+	}
+	return l.ReturnedAt.Sub(l.BorrowedAt)
+}
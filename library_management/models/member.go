@@ -5,4 +5,5 @@ type Member struct {
 	ID            int    `json:"id"`
 	Name          string `json:"name"`
 	BorrowedBooks []Book `json:"borrowed_books"`
+	History       []Loan `json:"history"` // completed loans, oldest first
 }
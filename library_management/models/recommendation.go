@@ -0,0 +1,11 @@
+package models
+
+// Recommendation is a book suggested to a member or alongside another book.
+type Recommendation struct {
+	BookID int    `json:"book_id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Genre  string `json:"genre,omitempty"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// TitleCount is how many times a book has been borrowed.
+type TitleCount struct {
+	BookID int    `json:"book_id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Loans  int    `json:"loans"`
+}
+
+// MemberActivity is how many loans a member has taken out.
+type MemberActivity struct {
+	MemberID int    `json:"member_id"`
+	Name     string `json:"name"`
+	Loans    int    `json:"loans"`
+}
+
+// LibraryReport summarises borrowing activity across the library.
+type LibraryReport struct {
+	TotalLoans         int              `json:"total_loans"`
+	ActiveLoans        int              `json:"active_loans"`
+	AverageLoanSeconds float64          `json:"average_loan_seconds"` // over returned loans only
+	MostBorrowed       []TitleCount     `json:"most_borrowed"`
+	ActiveMembers      []MemberActivity `json:"active_members"`
+}
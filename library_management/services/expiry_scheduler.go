@@ -0,0 +1,201 @@
+package services
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// expiryItem is a single scheduled deadline.
+type expiryItem struct {
+	key   int
+	at    time.Time
+	index int // position in the heap, maintained by expiryHeap
+}
+
+// expiryHeap is a min-heap of deadlines ordered by expiry time.
+type expiryHeap []*expiryItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	item := x.(*expiryItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// expiryScheduler fires a callback for each key whose deadline has passed.
+// A single goroutine sleeps until the earliest deadline in a min-heap, so the
+// cost of scheduling, cancelling or extending is O(log n) no matter how many
+// deadlines are pending.
+//
+// onExpire is called from the scheduler goroutine without the scheduler's lock
+// held, so it may freely call back into the scheduler. Callers that hold their
+// own lock while calling Schedule/Cancel/Extend must take that lock before the
+// scheduler's, never the other way round.
+type expiryScheduler struct {
+	mu       sync.Mutex
+	items    expiryHeap
+	byKey    map[int]*expiryItem
+	onExpire func(key int)
+
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newExpiryScheduler creates a scheduler and starts its goroutine.
+func newExpiryScheduler(onExpire func(key int)) *expiryScheduler {
+	s := &expiryScheduler{
+		byKey:    make(map[int]*expiryItem),
+		onExpire: onExpire,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Schedule sets the deadline for key, replacing any existing one.
+func (s *expiryScheduler) Schedule(key int, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, ok := s.byKey[key]; ok {
+		item.at = at
+		heap.Fix(&s.items, item.index)
+	} else {
+		item = &expiryItem{key: key, at: at}
+		heap.Push(&s.items, item)
+		s.byKey[key] = item
+	}
+	s.signal()
+}
+
+// Cancel removes the deadline for key. It reports whether one was pending.
+func (s *expiryScheduler) Cancel(key int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.byKey[key]
+	if !ok {
+		return false
+	}
+	heap.Remove(&s.items, item.index)
+	delete(s.byKey, key)
+	s.signal()
+	return true
+}
+
+// Extend pushes the deadline for key back by d. It reports whether one was pending.
+func (s *expiryScheduler) Extend(key int, d time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.byKey[key]
+	if !ok {
+		return false
+	}
+	item.at = item.at.Add(d)
+	heap.Fix(&s.items, item.index)
+	s.signal()
+	return true
+}
+
+// Deadline returns the pending deadline for key, if any.
+func (s *expiryScheduler) Deadline(key int) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.byKey[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return item.at, true
+}
+
+// Len returns the number of pending deadlines.
+func (s *expiryScheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// Stop shuts the scheduler goroutine down and waits for it to exit.
+// Pending deadlines are dropped; Stop is safe to call more than once.
+func (s *expiryScheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+// signal nudges the scheduler goroutine to re-check the earliest deadline.
+// Must be called with s.mu held.
+func (s *expiryScheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// a wake-up is already pending
+	}
+}
+
+// popDue removes and returns the keys of every deadline at or before now,
+// along with the next deadline still pending (zero if none).
+func (s *expiryScheduler) popDue(now time.Time) ([]int, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []int
+	for len(s.items) > 0 && !s.items[0].at.After(now) {
+		item := heap.Pop(&s.items).(*expiryItem)
+		delete(s.byKey, item.key)
+		due = append(due, item.key)
+	}
+	if len(s.items) == 0 {
+		return due, time.Time{}
+	}
+	return due, s.items[0].at
+}
+
+func (s *expiryScheduler) run() {
+	defer close(s.done)
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		due, next := s.popDue(time.Now())
+		for _, key := range due {
+			s.onExpire(key)
+		}
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-s.stop:
+			return
+		}
+		timer.Stop()
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestScheduler returns a scheduler whose deadlines are all relative to a
+// base an hour in the future, so its own goroutine never fires them and the
+// tests drive expiry through popDue with a clock of their own.
+func newTestScheduler(t *testing.T) (*expiryScheduler, time.Time) {
+	t.Helper()
+	s := newExpiryScheduler(func(key int) { t.Errorf("key %d expired on the wall clock", key) })
+	t.Cleanup(s.Stop)
+	return s, time.Now().Add(time.Hour)
+}
+
+func TestExpirySchedulerPopsInDeadlineOrder(t *testing.T) {
+	s, base := newTestScheduler(t)
+	offsets := map[int]time.Duration{1: 40, 2: 10, 3: 50, 4: 20, 5: 30}
+	for key, off := range offsets {
+		s.Schedule(key, base.Add(off*time.Minute))
+	}
+	if s.Len() != len(offsets) {
+		t.Fatalf("got %d pending deadlines, want %d", s.Len(), len(offsets))
+	}
+
+	due, next := s.popDue(base.Add(25 * time.Minute))
+	if !slices.Equal(due, []int{2, 4}) {
+		t.Errorf("got %v due, want [2 4]", due)
+	}
+	if !next.Equal(base.Add(30 * time.Minute)) {
+		t.Errorf("got next deadline %v, want %v", next, base.Add(30*time.Minute))
+	}
+
+	due, next = s.popDue(base.Add(time.Hour))
+	if !slices.Equal(due, []int{5, 1, 3}) {
+		t.Errorf("got %v due, want [5 1 3]", due)
+	}
+	if !next.IsZero() || s.Len() != 0 {
+		t.Errorf("got next deadline %v with %d pending, want none", next, s.Len())
+	}
+}
+
+func TestExpirySchedulerExpiresAtDeadline(t *testing.T) {
+	s, base := newTestScheduler(t)
+	s.Schedule(1, base)
+
+	if due, _ := s.popDue(base.Add(-time.Nanosecond)); len(due) != 0 {
+		t.Errorf("got %v due before the deadline", due)
+	}
+	if due, _ := s.popDue(base); !slices.Equal(due, []int{1}) {
+		t.Errorf("got %v due at the deadline, want [1]", due)
+	}
+	if _, pending := s.Deadline(1); pending {
+		t.Error("deadline still pending after it expired")
+	}
+}
+
+func TestExpirySchedulerExtendDelaysExpiry(t *testing.T) {
+	s, base := newTestScheduler(t)
+	s.Schedule(1, base)
+	s.Schedule(2, base.Add(time.Minute))
+
+	if !s.Extend(1, 2*time.Minute) {
+		t.Fatal("Extend reported no pending deadline")
+	}
+	if at, _ := s.Deadline(1); !at.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("got deadline %v, want %v", at, base.Add(2*time.Minute))
+	}
+	if due, next := s.popDue(base); len(due) != 0 || !next.Equal(base.Add(time.Minute)) {
+		t.Errorf("got %v due and next %v, want none due and next %v", due, next, base.Add(time.Minute))
+	}
+	if due, _ := s.popDue(base.Add(2 * time.Minute)); !slices.Equal(due, []int{2, 1}) {
+		t.Errorf("got %v due, want [2 1]", due)
+	}
+	if s.Extend(1, time.Minute) {
+		t.Error("Extend reported a pending deadline after it expired")
+	}
+}
+
+func TestExpirySchedulerScheduleReplacesDeadline(t *testing.T) {
+	s, base := newTestScheduler(t)
+	s.Schedule(1, base.Add(time.Minute))
+	s.Schedule(2, base.Add(2*time.Minute))
+	s.Schedule(2, base)
+
+	if s.Len() != 2 {
+		t.Fatalf("got %d pending deadlines, want 2", s.Len())
+	}
+	if due, _ := s.popDue(base); !slices.Equal(due, []int{2}) {
+		t.Errorf("got %v due, want [2]", due)
+	}
+}
+
+func TestExpirySchedulerCancel(t *testing.T) {
+	s, base := newTestScheduler(t)
+	s.Schedule(1, base)
+	s.Schedule(2, base.Add(time.Minute))
+
+	if !s.Cancel(1) {
+		t.Fatal("Cancel reported no pending deadline")
+	}
+	if s.Cancel(1) {
+		t.Error("second Cancel reported a pending deadline")
+	}
+	if due, _ := s.popDue(base.Add(time.Minute)); !slices.Equal(due, []int{2}) {
+		t.Errorf("got %v due, want [2]", due)
+	}
+}
+
+func TestExpirySchedulerRunFiresCallback(t *testing.T) {
+	fired := make(chan int, 1)
+	s := newExpiryScheduler(func(key int) { fired <- key })
+	defer s.Stop()
+
+	s.Schedule(7, time.Now().Add(-time.Second))
+	select {
+	case key := <-fired:
+		if key != 7 {
+			t.Errorf("got key %d, want 7", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expired deadline never fired")
+	}
+	s.Stop() // Stop is safe to call twice
+}
+
+// expireDue fires every reservation deadline at or before now, as the
+// scheduler goroutine would once the clock reaches it.
+func expireDue(l *Library, now time.Time) []int {
+	due, _ := l.expiry.popDue(now)
+	for _, bookID := range due {
+		l.expireReservation(bookID)
+	}
+	return due
+}
+
+func TestLibraryReservationExpires(t *testing.T) {
+	l := newTestLibrary(t)
+	if err := l.ReserveBook(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	deadline, pending := l.expiry.Deadline(1)
+	if !pending {
+		t.Fatal("reservation has no pending deadline")
+	}
+
+	if due := expireDue(l, deadline.Add(-time.Nanosecond)); len(due) != 0 {
+		t.Fatalf("got %v expired before the deadline", due)
+	}
+	if got := bookOf(t, l, 1); got.ReservedBy != 1 {
+		t.Fatalf("got reserved by %d before the deadline, want 1", got.ReservedBy)
+	}
+
+	if due := expireDue(l, deadline); !slices.Equal(due, []int{1}) {
+		t.Fatalf("got %v expired, want [1]", due)
+	}
+	if got := bookOf(t, l, 1); got.ReservedBy != 0 || !got.ReservedAt.IsZero() {
+		t.Errorf("reservation left in place after expiry: %+v", got)
+	}
+	if err := l.BorrowBook(1, 2); err != nil {
+		t.Errorf("another member could not borrow the expired reservation: %v", err)
+	}
+}
+
+func TestLibraryExtendedReservationOutlivesOldDeadline(t *testing.T) {
+	l := newTestLibrary(t)
+	if err := l.ReserveBook(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	deadline, _ := l.expiry.Deadline(1)
+	if err := l.ExtendReservation(1, 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if due := expireDue(l, deadline); len(due) != 0 {
+		t.Errorf("got %v expired at the old deadline", due)
+	}
+	if due := expireDue(l, deadline.Add(time.Minute)); !slices.Equal(due, []int{1}) {
+		t.Errorf("got %v expired at the new deadline, want [1]", due)
+	}
+}
+
+func TestLibraryExtendStandsDownFiredDeadline(t *testing.T) {
+	l := newTestLibrary(t)
+	if err := l.ReserveBook(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	deadline, _ := l.expiry.Deadline(1)
+
+	// the deadline fires but its callback has not got the lock yet
+	due, _ := l.expiry.popDue(deadline)
+	if err := l.ExtendReservation(1, 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for _, bookID := range due {
+		l.expireReservation(bookID)
+	}
+	if got := bookOf(t, l, 1); got.ReservedBy != 1 {
+		t.Errorf("extended reservation expired: %+v", got)
+	}
+}
+
+func TestLibraryCancelledReservationDoesNotExpire(t *testing.T) {
+	l := newTestLibrary(t)
+	if err := l.ReserveBook(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	deadline, _ := l.expiry.Deadline(1)
+	if err := l.CancelReservation(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ReserveBook(1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// member 2's hold started later, so member 1's old deadline has nothing to expire
+	if due := expireDue(l, deadline.Add(-time.Nanosecond)); len(due) != 0 {
+		t.Errorf("got %v expired, want none", due)
+	}
+	if got := bookOf(t, l, 1); got.ReservedBy != 2 {
+		t.Errorf("got reserved by %d, want 2", got.ReservedBy)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,25 +21,62 @@ type LibraryManager interface {
 	AddMember(m models.Member) error
 	GetMember(memberID int) (*models.Member, error)
 	ReserveBook(bookID int, memberID int) error
+	CancelReservation(bookID int, memberID int) error
+	ExtendReservation(bookID int, memberID int, d time.Duration) error
+	MemberHistory(memberID int) ([]models.Loan, error)
+	Report(top int) models.LibraryReport
+	AlsoBorrowed(bookID int, n int) ([]models.Recommendation, error)
+	RecommendForMember(memberID int, n int) ([]models.Recommendation, error)
 }
 
 // Library implements LibraryManager with concurrency support.
+//
+// All state is guarded by a single sync.RWMutex. Read-only operations
+// (ListAvailableBooks, ListBorrowedBooks, GetMember) take the read lock so
+// they can run in parallel with each other; anything that mutates books,
+// members or reservations takes the write lock. Never call another locking
+// Library method while holding mu.
+//
+// Reservation holds are expired by a single expiryScheduler owned by the
+// Library. Lock order is mu first, then the scheduler's internal lock; the
+// scheduler never holds its lock while calling back into the Library.
 type Library struct {
 	books        map[int]models.Book
 	members      map[int]models.Member
-	reservations map[int]int          // bookID -> memberID
-	timers       map[int]*time.Timer  // bookID -> auto-cancel timer
-	mu           sync.Mutex
+	reservations map[int]int         // bookID -> memberID
+	loans        map[int]models.Loan // bookID -> active loan
+	expiry       *expiryScheduler
+	recs         *recommender
+	maxExtends   int
+	mu           sync.RWMutex
 }
 
-// NewLibrary creates a new Library instance.
+// reservationHold is how long a reservation lasts before it is auto-cancelled.
+const reservationHold = 5 * time.Second
+
+// DefaultMaxExtensions is how many times a reservation can be extended
+// unless changed with SetMaxExtensions.
+const DefaultMaxExtensions = 2
+
+// NewLibrary creates a new Library instance and starts its expiry scheduler.
+// Call Close when the library is no longer needed.
 func NewLibrary() *Library {
-	return &Library{
+	l := &Library{
 		books:        make(map[int]models.Book),
 		members:      make(map[int]models.Member),
 		reservations: make(map[int]int),
-		timers:       make(map[int]*time.Timer),
+		loans:        make(map[int]models.Loan),
+		recs:         newRecommender(),
+		maxExtends:   DefaultMaxExtensions,
 	}
+	l.expiry = newExpiryScheduler(l.expireReservation)
+	return l
+}
+
+// Close stops the reservation expiry scheduler. Reservations still pending are
+// left in place but will no longer be auto-cancelled.
+func (l *Library) Close() {
+	l.expiry.Stop()
 }
 
 // AddBook adds a new book to the library.
@@ -48,7 +86,11 @@ func (l *Library) AddBook(book models.Book) {
 	if book.Status == "" {
 		book.Status = "Available"
 	}
+	if old, exists := l.books[book.ID]; exists {
+		l.recs.unindexBook(old)
+	}
 	l.books[book.ID] = book
+	l.recs.indexBook(book)
 }
 
 // RemoveBook removes a book from the library by its ID.
@@ -67,6 +109,7 @@ func (l *Library) RemoveBook(bookID int) error {
 		return errors.New("cannot remove a reserved book")
 	}
 	delete(l.books, bookID)
+	l.recs.unindexBook(b)
 	return nil
 }
 
@@ -79,14 +122,15 @@ func (l *Library) AddMember(m models.Member) error {
 		return errors.New("member with this ID already exists")
 	}
 	m.BorrowedBooks = []models.Book{}
+	m.History = []models.Loan{}
 	l.members[m.ID] = m
 	return nil
 }
 
 // GetMember returns a pointer to a member if exists.
 func (l *Library) GetMember(memberID int) (*models.Member, error) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 
 	m, ok := l.members[memberID]
 	if !ok {
@@ -94,6 +138,8 @@ func (l *Library) GetMember(memberID int) (*models.Member, error) {
 	}
 	// Return a copy to avoid exposing internal state
 	copyM := m
+	copyM.BorrowedBooks = append([]models.Book{}, m.BorrowedBooks...)
+	copyM.History = append([]models.Loan{}, m.History...)
 	return &copyM, nil
 }
 
@@ -117,28 +163,37 @@ func (l *Library) BorrowBook(bookID int, memberID int) error {
 		return errors.New("book reserved by another member")
 	}
 
+	member, ok := l.members[memberID]
+	if !ok {
+		return errors.New("member not found")
+	}
+
 	// mark book as borrowed
 	book.Status = "Borrowed"
 	book.ReservedBy = 0
 	book.ReservedAt = time.Time{}
+	book.Extensions = 0
 	l.books[bookID] = book
 
-	// cancel auto-cancel timer if exists
-	if timer, exists := l.timers[bookID]; exists {
-		timer.Stop()
-		delete(l.timers, bookID)
-	}
+	// cancel pending auto-cancel if exists
+	l.expiry.Cancel(bookID)
 	// remove reservation entry
 	delete(l.reservations, bookID)
 
 	// attach to member
-	member, ok := l.members[memberID]
-	if !ok {
-		return errors.New("member not found")
-	}
 	member.BorrowedBooks = append(member.BorrowedBooks, book)
 	l.members[memberID] = member
 
+	// open a loan record
+	l.loans[bookID] = models.Loan{
+		BookID:     book.ID,
+		Title:      book.Title,
+		Author:     book.Author,
+		MemberID:   memberID,
+		BorrowedAt: time.Now(),
+	}
+	l.recs.recordBorrow(memberID, book)
+
 	return nil
 }
 
@@ -171,6 +226,14 @@ func (l *Library) ReturnBook(bookID int, memberID int) error {
 
 	// remove from member
 	member.BorrowedBooks = append(member.BorrowedBooks[:foundIdx], member.BorrowedBooks[foundIdx+1:]...)
+
+	// close the loan record and keep it in the member's history
+	if loan, ok := l.loans[bookID]; ok {
+		returned := time.Now()
+		loan.ReturnedAt = &returned
+		member.History = append(member.History, loan)
+		delete(l.loans, bookID)
+	}
 	l.members[memberID] = member
 
 	// update book to available (note: not reserved)
@@ -182,8 +245,8 @@ func (l *Library) ReturnBook(bookID int, memberID int) error {
 
 // ListAvailableBooks lists all available books.
 func (l *Library) ListAvailableBooks() []models.Book {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 
 	list := make([]models.Book, 0, len(l.books))
 	for _, b := range l.books {
@@ -196,8 +259,8 @@ func (l *Library) ListAvailableBooks() []models.Book {
 
 // ListBorrowedBooks lists all books borrowed by a specific member.
 func (l *Library) ListBorrowedBooks(memberID int) ([]models.Book, error) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 
 	member, ok := l.members[memberID]
 	if !ok {
@@ -209,8 +272,118 @@ func (l *Library) ListBorrowedBooks(memberID int) ([]models.Book, error) {
 	return copySlice, nil
 }
 
+// MemberHistory lists a member's completed loans, oldest first.
+func (l *Library) MemberHistory(memberID int) ([]models.Loan, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	member, ok := l.members[memberID]
+	if !ok {
+		return nil, errors.New("member not found")
+	}
+	// return a copy
+	copySlice := make([]models.Loan, len(member.History))
+	copy(copySlice, member.History)
+	return copySlice, nil
+}
+
+// Report summarises borrowing across the library: the top most-borrowed
+// titles, the top most active members (both counting active and completed
+// loans) and the average length of returned loans.
+func (l *Library) Report(top int) models.LibraryReport {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	titles := make(map[int]*models.TitleCount)
+	memberLoans := make(map[int]int)
+	count := func(loan models.Loan) {
+		tc, ok := titles[loan.BookID]
+		if !ok {
+			tc = &models.TitleCount{BookID: loan.BookID, Title: loan.Title, Author: loan.Author}
+			titles[loan.BookID] = tc
+		}
+		tc.Loans++
+		memberLoans[loan.MemberID]++
+	}
+
+	report := models.LibraryReport{ActiveLoans: len(l.loans)}
+	var returned int
+	var total time.Duration
+	for _, m := range l.members {
+		for _, loan := range m.History {
+			count(loan)
+			returned++
+			total += loan.Duration()
+		}
+	}
+	for _, loan := range l.loans {
+		count(loan)
+	}
+	report.TotalLoans = returned + report.ActiveLoans
+	if returned > 0 {
+		report.AverageLoanSeconds = (total / time.Duration(returned)).Seconds()
+	}
+
+	report.MostBorrowed = make([]models.TitleCount, 0, len(titles))
+	for _, tc := range titles {
+		report.MostBorrowed = append(report.MostBorrowed, *tc)
+	}
+	sort.Slice(report.MostBorrowed, func(i, j int) bool {
+		a, b := report.MostBorrowed[i], report.MostBorrowed[j]
+		if a.Loans != b.Loans {
+			return a.Loans > b.Loans
+		}
+		return a.BookID < b.BookID
+	})
+
+	report.ActiveMembers = make([]models.MemberActivity, 0, len(memberLoans))
+	for id, n := range memberLoans {
+		report.ActiveMembers = append(report.ActiveMembers, models.MemberActivity{MemberID: id, Name: l.members[id].Name, Loans: n})
+	}
+	sort.Slice(report.ActiveMembers, func(i, j int) bool {
+		a, b := report.ActiveMembers[i], report.ActiveMembers[j]
+		if a.Loans != b.Loans {
+			return a.Loans > b.Loans
+		}
+		return a.MemberID < b.MemberID
+	})
+
+	if top > 0 {
+		if len(report.MostBorrowed) > top {
+			report.MostBorrowed = report.MostBorrowed[:top]
+		}
+		if len(report.ActiveMembers) > top {
+			report.ActiveMembers = report.ActiveMembers[:top]
+		}
+	}
+	return report
+}
+
+// AlsoBorrowed suggests up to n books borrowed by members who also borrowed bookID.
+func (l *Library) AlsoBorrowed(bookID int, n int) ([]models.Recommendation, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if _, ok := l.books[bookID]; !ok {
+		return nil, errors.New("book not found")
+	}
+	return l.recs.alsoBorrowed(bookID, n, l.books), nil
+}
+
+// RecommendForMember suggests up to n books the member hasn't borrowed yet,
+// based on co-borrowing and the authors and genres they read.
+func (l *Library) RecommendForMember(memberID int, n int) ([]models.Recommendation, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if _, ok := l.members[memberID]; !ok {
+		return nil, errors.New("member not found")
+	}
+	return l.recs.forMember(memberID, n, l.books), nil
+}
+
 // ReserveBook reserves a book for a member. If reserved, it returns error.
-// A timer is scheduled to auto-cancel the reservation after 5 seconds if not borrowed.
+// The reservation is auto-cancelled after 5 seconds if not borrowed.
 func (l *Library) ReserveBook(bookID int, memberID int) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -234,38 +407,114 @@ func (l *Library) ReserveBook(bookID int, memberID int) error {
 	book.ReservedAt = time.Now()
 	l.books[bookID] = book
 
-	// schedule auto-cancel in 5 seconds
-	timer := time.AfterFunc(5*time.Second, func() {
-		l.mu.Lock()
-		defer l.mu.Unlock()
-		// only cancel if still reserved and not borrowed
-		if reserver, exists := l.reservations[bookID]; exists && reserver == memberID {
-			// double-check book status
-			if b, bok := l.books[bookID]; bok && b.Status != "Borrowed" {
-				delete(l.reservations, bookID)
-				if t, ok := l.timers[bookID]; ok {
-					t.Stop()
-				}
-				delete(l.timers, bookID)
-				// clear reservation metadata
-				b.ReservedBy = 0
-				b.ReservedAt = time.Time{}
-				l.books[bookID] = b
-				fmt.Printf("[AUTO-CANCEL] Reservation for book %d auto-cancelled (member %d)\n", bookID, memberID)
-			}
-		}
-	})
+	// schedule auto-cancel
+	l.expiry.Schedule(bookID, book.ReservedAt.Add(reservationHold))
+	return nil
+}
+
+// CancelReservation releases a reservation held by the member before it expires.
+func (l *Library) CancelReservation(bookID int, memberID int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	book, ok := l.books[bookID]
+	if !ok {
+		return errors.New("book not found")
+	}
+	reserver, exists := l.reservations[bookID]
+	if !exists {
+		return errors.New("book is not reserved")
+	}
+	if reserver != memberID {
+		return errors.New("book reserved by another member")
+	}
+
+	l.expiry.Cancel(bookID)
+	delete(l.reservations, bookID)
+	book.ReservedBy = 0
+	book.ReservedAt = time.Time{}
+	book.Extensions = 0
+	l.books[bookID] = book
+	return nil
+}
+
+// ExtendReservation pushes the member's reservation deadline back by d.
+// A reservation can be extended at most SetMaxExtensions times.
+func (l *Library) ExtendReservation(bookID int, memberID int, d time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	// store timer and return
-	l.timers[bookID] = timer
+	if d <= 0 {
+		return errors.New("extension must be positive")
+	}
+	book, ok := l.books[bookID]
+	if !ok {
+		return errors.New("book not found")
+	}
+	reserver, exists := l.reservations[bookID]
+	if !exists {
+		return errors.New("book is not reserved")
+	}
+	if reserver != memberID {
+		return errors.New("book reserved by another member")
+	}
+	if book.Extensions >= l.maxExtends {
+		return fmt.Errorf("reservation already extended %d times (max %d)", book.Extensions, l.maxExtends)
+	}
+
+	book.ReservedAt = book.ReservedAt.Add(d)
+	book.Extensions++
+	l.books[bookID] = book
+
+	// Schedule rather than Extend: if the old deadline has already fired and
+	// its callback is waiting on mu, the new deadline makes it stand down.
+	l.expiry.Schedule(bookID, book.ReservedAt.Add(reservationHold))
 	return nil
 }
 
+// SetMaxExtensions sets how many times a single reservation can be extended.
+func (l *Library) SetMaxExtensions(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	l.maxExtends = n
+}
+
+// expireReservation is called by the expiry scheduler when a reservation's
+// hold runs out, and cancels it if it is still in place.
+func (l *Library) expireReservation(bookID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the book was reserved again after this deadline fired
+	if _, pending := l.expiry.Deadline(bookID); pending {
+		return
+	}
+	memberID, exists := l.reservations[bookID]
+	if !exists {
+		return
+	}
+	// double-check book status
+	b, ok := l.books[bookID]
+	if !ok || b.Status == "Borrowed" {
+		return
+	}
+	delete(l.reservations, bookID)
+	// clear reservation metadata
+	b.ReservedBy = 0
+	b.ReservedAt = time.Time{}
+	b.Extensions = 0
+	l.books[bookID] = b
+	fmt.Printf("[AUTO-CANCEL] Reservation for book %d auto-cancelled (member %d)\n", bookID, memberID)
+}
+
 // SeedSampleData seeds the library with sample data.
 func (l *Library) SeedSampleData() {
-	l.AddBook(models.Book{ID: 1, Title: "1984", Author: "George Orwell"})
-	l.AddBook(models.Book{ID: 2, Title: "The Hobbit", Author: "J.R.R. Tolkien"})
-	l.AddBook(models.Book{ID: 3, Title: "Clean Code", Author: "Robert C. Martin"})
+	l.AddBook(models.Book{ID: 1, Title: "1984", Author: "George Orwell", Genre: "Dystopian"})
+	l.AddBook(models.Book{ID: 2, Title: "The Hobbit", Author: "J.R.R. Tolkien", Genre: "Fantasy"})
+	l.AddBook(models.Book{ID: 3, Title: "Clean Code", Author: "Robert C. Martin", Genre: "Programming"})
 	_ = l.AddMember(models.Member{ID: 1, Name: "Alice"})
 	_ = l.AddMember(models.Member{ID: 2, Name: "Bob"})
 	_ = l.AddMember(models.Member{ID: 3, Name: "Carol"})
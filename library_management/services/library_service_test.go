@@ -0,0 +1,283 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"library_management/models"
+)
+
+const (
+	benchBooks   = 200
+	benchMembers = 20
+)
+
+// newBenchLibrary returns a library of its own with benchBooks available
+// books and benchMembers members, each holding one borrowed book.
+func newBenchLibrary(b *testing.B) *Library {
+	b.Helper()
+	l := NewLibrary()
+	b.Cleanup(l.Close)
+	for id := 1; id <= benchBooks; id++ {
+		l.AddBook(models.Book{ID: id, Title: "Book", Author: "Author", Genre: "Genre"})
+	}
+	for id := 1; id <= benchMembers; id++ {
+		if err := l.AddMember(models.Member{ID: id, Name: "Member"}); err != nil {
+			b.Fatal(err)
+		}
+		if err := l.BorrowBook(id, id); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return l
+}
+
+// runReads runs the read-only Library calls in parallel until b.N
+// iterations are done.
+func runReads(b *testing.B, l *Library) {
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		memberID := int(next.Add(1))%benchMembers + 1
+		for pb.Next() {
+			l.ListAvailableBooks()
+			if _, err := l.GetMember(memberID); err != nil {
+				b.Error(err)
+			}
+			if _, err := l.ListBorrowedBooks(memberID); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+	b.StopTimer()
+}
+
+// BenchmarkReads measures the read-only calls on their own.
+func BenchmarkReads(b *testing.B) {
+	runReads(b, newBenchLibrary(b))
+}
+
+// BenchmarkReadsWithReservations measures the same reads while other
+// goroutines keep reserving and cancelling books, which take the write lock.
+// Reservations are cancelled straight away so no holds are left behind.
+func BenchmarkReadsWithReservations(b *testing.B) {
+	l := newBenchLibrary(b)
+	const reservers = 2
+	var (
+		stop         atomic.Bool
+		reservations atomic.Int64
+		wg           sync.WaitGroup
+	)
+	for w := 0; w < reservers; w++ {
+		wg.Add(1)
+		go func(memberID int) {
+			defer wg.Done()
+			// each reserver cycles through its own half of the unborrowed books
+			for n := 0; !stop.Load(); n++ {
+				bookID := benchMembers + 1 + (n*reservers+memberID-1)%(benchBooks-benchMembers)
+				if err := l.ReserveBook(bookID, memberID); err == nil {
+					reservations.Add(1)
+					_ = l.CancelReservation(bookID, memberID)
+				}
+			}
+		}(w + 1)
+	}
+	runReads(b, l)
+	stop.Store(true)
+	wg.Wait()
+	b.ReportMetric(float64(reservations.Load())/b.Elapsed().Seconds(), "reservations/s")
+}
+
+// newTestLibrary returns a library with three available books and two
+// members. Reservations last reservationHold, so the expiry scheduler's own
+// goroutine never fires during a test; expiry is driven by expireDue instead.
+func newTestLibrary(t *testing.T) *Library {
+	t.Helper()
+	l := NewLibrary()
+	t.Cleanup(l.Close)
+	for id := 1; id <= 3; id++ {
+		l.AddBook(models.Book{ID: id, Title: fmt.Sprintf("Book %d", id), Author: "Author", Genre: "Genre"})
+	}
+	for id := 1; id <= 2; id++ {
+		if err := l.AddMember(models.Member{ID: id, Name: "Member"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return l
+}
+
+// bookOf returns the library's current copy of a book.
+func bookOf(t *testing.T, l *Library, bookID int) models.Book {
+	t.Helper()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	book, ok := l.books[bookID]
+	if !ok {
+		t.Fatalf("book %d not found", bookID)
+	}
+	return book
+}
+
+func TestCancelReservation(t *testing.T) {
+	l := newTestLibrary(t)
+	if err := l.ReserveBook(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ExtendReservation(1, 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name             string
+		bookID, memberID int
+		wantErr          string
+	}{
+		{"unknown book", 99, 1, "book not found"},
+		{"not reserved", 2, 1, "book is not reserved"},
+		{"another member", 1, 2, "book reserved by another member"},
+	} {
+		if err := l.CancelReservation(tc.bookID, tc.memberID); err == nil || err.Error() != tc.wantErr {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+
+	if err := l.CancelReservation(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := bookOf(t, l, 1); got.ReservedBy != 0 || !got.ReservedAt.IsZero() || got.Extensions != 0 {
+		t.Errorf("reservation left in place: %+v", got)
+	}
+	if _, pending := l.expiry.Deadline(1); pending {
+		t.Error("cancelled reservation still has a deadline")
+	}
+	if err := l.CancelReservation(1, 1); err == nil {
+		t.Error("cancelled the same reservation twice")
+	}
+	if err := l.ReserveBook(1, 2); err != nil {
+		t.Errorf("another member could not reserve the released book: %v", err)
+	}
+}
+
+func TestExtendReservation(t *testing.T) {
+	l := newTestLibrary(t)
+	if err := l.ReserveBook(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	reservedAt := bookOf(t, l, 1).ReservedAt
+
+	for _, tc := range []struct {
+		name             string
+		bookID, memberID int
+		d                time.Duration
+		wantErr          string
+	}{
+		{"zero extension", 1, 1, 0, "extension must be positive"},
+		{"negative extension", 1, 1, -time.Minute, "extension must be positive"},
+		{"unknown book", 99, 1, time.Minute, "book not found"},
+		{"not reserved", 2, 1, time.Minute, "book is not reserved"},
+		{"another member", 1, 2, time.Minute, "book reserved by another member"},
+	} {
+		if err := l.ExtendReservation(tc.bookID, tc.memberID, tc.d); err == nil || err.Error() != tc.wantErr {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+
+	for i := 1; i <= DefaultMaxExtensions; i++ {
+		if err := l.ExtendReservation(1, 1, time.Minute); err != nil {
+			t.Fatalf("extension %d: %v", i, err)
+		}
+		book := bookOf(t, l, 1)
+		wantAt := reservedAt.Add(time.Duration(i) * time.Minute)
+		if !book.ReservedAt.Equal(wantAt) || book.Extensions != i {
+			t.Errorf("extension %d: got reserved at %v with %d extensions, want %v with %d",
+				i, book.ReservedAt, book.Extensions, wantAt, i)
+		}
+		if deadline, _ := l.expiry.Deadline(1); !deadline.Equal(wantAt.Add(reservationHold)) {
+			t.Errorf("extension %d: got deadline %v, want %v", i, deadline, wantAt.Add(reservationHold))
+		}
+	}
+
+	wantErr := fmt.Sprintf("reservation already extended %d times (max %d)", DefaultMaxExtensions, DefaultMaxExtensions)
+	if err := l.ExtendReservation(1, 1, time.Minute); err == nil || err.Error() != wantErr {
+		t.Errorf("got %v past the limit, want %q", err, wantErr)
+	}
+	l.SetMaxExtensions(DefaultMaxExtensions + 1)
+	if err := l.ExtendReservation(1, 1, time.Minute); err != nil {
+		t.Errorf("raised limit: %v", err)
+	}
+}
+
+func TestExtensionsResetWithNewReservation(t *testing.T) {
+	l := newTestLibrary(t)
+	l.SetMaxExtensions(1)
+	if err := l.ReserveBook(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ExtendReservation(1, 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CancelReservation(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ReserveBook(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ExtendReservation(1, 2, time.Minute); err != nil {
+		t.Errorf("new reservation inherited the old one's extensions: %v", err)
+	}
+
+	l.SetMaxExtensions(-1)
+	if err := l.ReserveBook(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ExtendReservation(2, 1, time.Minute); err == nil {
+		t.Error("extended a reservation with extensions disabled")
+	}
+}
+
+func TestMemberHistory(t *testing.T) {
+	l := newTestLibrary(t)
+	for _, bookID := range []int{2, 1} {
+		if err := l.BorrowBook(bookID, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.ReturnBook(bookID, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.BorrowBook(3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := l.MemberHistory(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].BookID != 2 || history[1].BookID != 1 {
+		t.Fatalf("got %+v, want the returned loans of books 2 then 1", history)
+	}
+	for _, loan := range history {
+		if loan.MemberID != 1 || loan.Title != fmt.Sprintf("Book %d", loan.BookID) {
+			t.Errorf("loan has the wrong book or member: %+v", loan)
+		}
+		if loan.ReturnedAt == nil || loan.ReturnedAt.Before(loan.BorrowedAt) || loan.Duration() < 0 {
+			t.Errorf("loan not closed properly: %+v", loan)
+		}
+	}
+
+	// the result is a copy
+	history[0].BookID = 99
+	if again, _ := l.MemberHistory(1); again[0].BookID != 2 {
+		t.Error("changing the returned history changed the member's")
+	}
+
+	if history, err := l.MemberHistory(2); err != nil || len(history) != 0 {
+		t.Errorf("got %v, %v for a member with no loans, want an empty history", history, err)
+	}
+	if _, err := l.MemberHistory(99); err == nil {
+		t.Error("got history for an unknown member")
+	}
+}
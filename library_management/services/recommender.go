@@ -0,0 +1,191 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"library_management/models"
+)
+
+// Weights used when scoring suggestions for a member. Co-borrowing is the
+// strongest signal; a shared genre is the weakest.
+const (
+	coBorrowWeight = 3
+	authorWeight   = 2
+	genreWeight    = 1
+)
+
+// recommender keeps co-borrowing counts and author/genre indexes up to date
+// as books are added, removed and borrowed, so queries never rescan history.
+// It has no lock of its own: every method must be called with Library.mu held
+// (the write lock for record*/index*, at least the read lock for queries).
+type recommender struct {
+	memberBooks   map[int]map[int]bool    // memberID -> distinct books ever borrowed
+	coBorrow      map[int]map[int]int     // bookID -> other bookID -> members who borrowed both
+	memberAuthors map[int]map[string]int  // memberID -> author -> loans
+	memberGenres  map[int]map[string]int  // memberID -> genre -> loans
+	byAuthor      map[string]map[int]bool // author -> bookIDs in the catalogue
+	byGenre       map[string]map[int]bool // genre -> bookIDs in the catalogue
+}
+
+func newRecommender() *recommender {
+	return &recommender{
+		memberBooks:   make(map[int]map[int]bool),
+		coBorrow:      make(map[int]map[int]int),
+		memberAuthors: make(map[int]map[string]int),
+		memberGenres:  make(map[int]map[string]int),
+		byAuthor:      make(map[string]map[int]bool),
+		byGenre:       make(map[string]map[int]bool),
+	}
+}
+
+// indexBook adds a catalogue book to the author and genre indexes.
+func (r *recommender) indexBook(b models.Book) {
+	if b.Author != "" {
+		if r.byAuthor[b.Author] == nil {
+			r.byAuthor[b.Author] = make(map[int]bool)
+		}
+		r.byAuthor[b.Author][b.ID] = true
+	}
+	if b.Genre != "" {
+		if r.byGenre[b.Genre] == nil {
+			r.byGenre[b.Genre] = make(map[int]bool)
+		}
+		r.byGenre[b.Genre][b.ID] = true
+	}
+}
+
+// unindexBook removes a catalogue book from the author and genre indexes.
+// Co-borrowing counts are kept; queries skip books no longer in the catalogue.
+func (r *recommender) unindexBook(b models.Book) {
+	delete(r.byAuthor[b.Author], b.ID)
+	if len(r.byAuthor[b.Author]) == 0 {
+		delete(r.byAuthor, b.Author)
+	}
+	delete(r.byGenre[b.Genre], b.ID)
+	if len(r.byGenre[b.Genre]) == 0 {
+		delete(r.byGenre, b.Genre)
+	}
+}
+
+// recordBorrow updates the counts for a single BorrowBook event.
+func (r *recommender) recordBorrow(memberID int, b models.Book) {
+	if b.Author != "" {
+		if r.memberAuthors[memberID] == nil {
+			r.memberAuthors[memberID] = make(map[string]int)
+		}
+		r.memberAuthors[memberID][b.Author]++
+	}
+	if b.Genre != "" {
+		if r.memberGenres[memberID] == nil {
+			r.memberGenres[memberID] = make(map[string]int)
+		}
+		r.memberGenres[memberID][b.Genre]++
+	}
+
+	seen := r.memberBooks[memberID]
+	if seen == nil {
+		seen = make(map[int]bool)
+		r.memberBooks[memberID] = seen
+	}
+	// re-borrowing the same book says nothing new about co-borrowing
+	if seen[b.ID] {
+		return
+	}
+	for other := range seen {
+		r.bump(b.ID, other)
+		r.bump(other, b.ID)
+	}
+	seen[b.ID] = true
+}
+
+func (r *recommender) bump(a, b int) {
+	if r.coBorrow[a] == nil {
+		r.coBorrow[a] = make(map[int]int)
+	}
+	r.coBorrow[a][b]++
+}
+
+// alsoBorrowed returns up to n catalogue books most often borrowed by members
+// who also borrowed bookID.
+func (r *recommender) alsoBorrowed(bookID int, n int, books map[int]models.Book) []models.Recommendation {
+	var recs []models.Recommendation
+	for other, count := range r.coBorrow[bookID] {
+		b, ok := books[other]
+		if !ok {
+			continue
+		}
+		recs = append(recs, recommendation(b, count, fmt.Sprintf("borrowed by %d member(s) who also borrowed this", count)))
+	}
+	return rank(recs, n)
+}
+
+// forMember returns up to n catalogue books the member hasn't borrowed yet,
+// scored by co-borrowing with their past loans and by the authors and genres
+// they read most.
+func (r *recommender) forMember(memberID int, n int, books map[int]models.Book) []models.Recommendation {
+	seen := r.memberBooks[memberID]
+	scores := make(map[int]int)
+	reasons := make(map[int]string)
+	best := make(map[int]int)
+	add := func(bookID, score int, reason string) {
+		if seen[bookID] {
+			return
+		}
+		if _, ok := books[bookID]; !ok {
+			return
+		}
+		scores[bookID] += score
+		if score > best[bookID] {
+			best[bookID] = score
+			reasons[bookID] = reason
+		}
+	}
+
+	for mine := range seen {
+		for other, count := range r.coBorrow[mine] {
+			add(other, count*coBorrowWeight, fmt.Sprintf("often borrowed with %q", books[mine].Title))
+		}
+	}
+	for author, count := range r.memberAuthors[memberID] {
+		for bookID := range r.byAuthor[author] {
+			add(bookID, count*authorWeight, fmt.Sprintf("by %s, an author you have read", author))
+		}
+	}
+	for genre, count := range r.memberGenres[memberID] {
+		for bookID := range r.byGenre[genre] {
+			add(bookID, count*genreWeight, fmt.Sprintf("in %s, a genre you read", genre))
+		}
+	}
+
+	recs := make([]models.Recommendation, 0, len(scores))
+	for bookID, score := range scores {
+		recs = append(recs, recommendation(books[bookID], score, reasons[bookID]))
+	}
+	return rank(recs, n)
+}
+
+func recommendation(b models.Book, score int, reason string) models.Recommendation {
+	return models.Recommendation{
+		BookID: b.ID,
+		Title:  b.Title,
+		Author: b.Author,
+		Genre:  b.Genre,
+		Score:  score,
+		Reason: reason,
+	}
+}
+
+// rank sorts by score (highest first, then book ID) and keeps the top n; n <= 0 keeps all.
+func rank(recs []models.Recommendation, n int) []models.Recommendation {
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].BookID < recs[j].BookID
+	})
+	if n > 0 && len(recs) > n {
+		recs = recs[:n]
+	}
+	return recs
+}
@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"task_manager/data"
+	"task_manager/models"
+)
+
+const (
+	// maxBulkBodyBytes bounds the size of a POST /tasks/bulk body.
+	maxBulkBodyBytes = 4 << 20
+	// maxBulkOperations bounds the number of operations in one bulk request.
+	maxBulkOperations = 100
+)
+
+// Bulk operation kinds.
+const (
+	bulkCreate = "create"
+	bulkUpdate = "update"
+	bulkDelete = "delete"
+)
+
+type bulkRequest struct {
+	// RollbackOnError applies nothing if an operation is invalid and undoes
+	// the applied operations once one fails. It is best-effort, not a
+	// transaction: see rollbackBulk.
+	RollbackOnError bool            `json:"rollback_on_error"`
+	Operations      []bulkOperation `json:"operations" binding:"required,min=1"`
+}
+
+// bulkOperation is one item of a bulk request. Create takes Task, update
+// takes ID and Task (PUT semantics) and delete takes ID. Version, if set, must
+// match the stored task for update and delete, like If-Match.
+type bulkOperation struct {
+	Op      string          `json:"op"`
+	ID      string          `json:"id"`
+	Version int             `json:"version"`
+	Task    json.RawMessage `json:"task"`
+}
+
+// bulkResult reports the outcome of one operation with the status and body
+// the single-task endpoint would have answered with.
+type bulkResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Body   any    `json:"body"`
+}
+
+// bulkStep is an operation ready to be applied: its request body has been
+// decoded and validated.
+type bulkStep struct {
+	op   bulkOperation
+	task models.Task
+	// undo reverts the operation once applied; set when rolling back on error.
+	undo func() error
+}
+
+// BulkTasks creates, updates and deletes several tasks in one request.
+// Operations are applied in order. By default each one succeeds or fails on
+// its own and the response is 200. With rollback_on_error, nothing is applied
+// unless every operation is valid (422 otherwise), and once one fails the
+// ones already applied are undone (409, or 500 if an undo failed).
+func (tc *TaskController) BulkTasks(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBulkBodyBytes)
+	var req bulkRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	if len(req.Operations) > maxBulkOperations {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("at most %d operations are allowed per request", maxBulkOperations)})
+		return
+	}
+
+	rollback := req.RollbackOnError
+	store := tc.storeFor(ctx)
+	results := make([]bulkResult, len(req.Operations))
+	steps := make([]*bulkStep, len(req.Operations))
+	valid := true
+	for i, op := range req.Operations {
+		results[i] = bulkResult{Index: i, Op: op.Op, ID: op.ID}
+		step, status, body := tc.prepareBulk(op)
+		if step == nil {
+			results[i].Status, results[i].Body = status, body
+			valid = false
+			continue
+		}
+		steps[i] = step
+	}
+
+	failed := !valid && rollback
+	applyFailed, undone := false, true
+	for i, step := range steps {
+		if step == nil {
+			continue
+		}
+		if failed {
+			results[i].Status, results[i].Body = http.StatusFailedDependency, gin.H{"error": "not applied because another operation failed"}
+			continue
+		}
+		task, err := tc.applyBulk(store, step, rollback)
+		if err != nil {
+			results[i].Status, results[i].Body = bulkErrorResponse(err)
+			if rollback {
+				failed, applyFailed = true, true
+				undone = rollbackBulk(steps[:i], results)
+			}
+			continue
+		}
+		results[i].ID = task.ID
+		switch step.op.Op {
+		case bulkCreate:
+			results[i].Status, results[i].Body = http.StatusCreated, task
+		case bulkUpdate:
+			results[i].Status, results[i].Body = http.StatusOK, task
+		case bulkDelete:
+			results[i].Status, results[i].Body = http.StatusOK, gin.H{"message": "task removed"}
+		}
+	}
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Status < 300 {
+			succeeded++
+		}
+	}
+	body := gin.H{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	}
+	status := http.StatusOK
+	switch {
+	case !failed:
+	case !applyFailed:
+		status = http.StatusUnprocessableEntity
+		body["error"] = "nothing was applied because some operations are invalid"
+	case undone:
+		status = http.StatusConflict
+		body["error"] = "an operation failed and the operations applied before it were rolled back"
+	default:
+		status = http.StatusInternalServerError
+		body["error"] = "an operation failed and some of the operations applied before it could not be rolled back"
+	}
+	ctx.JSON(status, body)
+}
+
+// prepareBulk checks an operation and decodes its task. On failure it returns
+// a nil step with the status and body to report.
+func (tc *TaskController) prepareBulk(op bulkOperation) (*bulkStep, int, any) {
+	step := &bulkStep{op: op}
+	switch op.Op {
+	case bulkCreate, bulkUpdate, bulkDelete:
+	default:
+		return nil, http.StatusBadRequest, gin.H{"error": `op must be one of: create, update, delete`}
+	}
+	if op.Op != bulkCreate && op.ID == "" {
+		return nil, http.StatusBadRequest, gin.H{"error": "id is required for " + op.Op}
+	}
+	if op.Op == bulkDelete {
+		return step, 0, nil
+	}
+
+	if len(op.Task) == 0 {
+		return nil, http.StatusBadRequest, gin.H{"error": "task is required for " + op.Op}
+	}
+	if err := json.Unmarshal(op.Task, &step.task); err != nil {
+		return nil, http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+	err := binding.Validator.ValidateStruct(&step.task)
+	if err == nil {
+		err = tc.checkProject(step.task.ProjectID)
+	}
+	if err != nil {
+		status, body := bulkErrorResponse(err)
+		return nil, status, body
+	}
+	return step, 0, nil
+}
+
+// applyBulk applies one prepared operation, recording how to undo it when
+// rollback is set.
+func (tc *TaskController) applyBulk(store data.TaskStore, step *bulkStep, rollback bool) (models.Task, error) {
+	op := step.op
+	var before models.Task
+	check := func(task models.Task) error {
+		before = task
+		if op.Version != 0 && task.Version != op.Version {
+			return errPreconditionFailed
+		}
+		return nil
+	}
+
+	switch op.Op {
+	case bulkCreate:
+		created, err := tc.createTask(store, step.task)
+		if err == nil && rollback {
+			step.undo = func() error {
+				return store.DeleteTask(created.ID, nil)
+			}
+		}
+		return created, err
+
+	case bulkUpdate:
+		updated, err := tc.replaceTask(store, op.ID, step.task, check)
+		if err == nil && rollback {
+			step.undo = func() error {
+				// completing a recurring task may have created its next occurrence
+				if updated.NextID != "" && updated.NextID != before.NextID {
+					if err := store.DeleteTask(updated.NextID, nil); err != nil && !errors.Is(err, data.ErrTaskNotFound) {
+						return err
+					}
+				}
+				_, err := tc.changeTask(store, op.ID, func(task *models.Task) error {
+					if task.Version != updated.Version {
+						return data.ErrUpdateConflict
+					}
+					*task = before
+					return nil
+				})
+				return err
+			}
+		}
+		return updated, err
+
+	default: // bulkDelete
+		err := store.DeleteTask(op.ID, check)
+		if err == nil && rollback {
+			step.undo = func() error {
+				_, err := tc.restoreTask(store, op.ID)
+				return err
+			}
+		}
+		return before, err
+	}
+}
+
+// rollbackBulk undoes the applied steps, newest first, and marks their
+// results. It reports whether every step was undone; one that cannot be,
+// e.g. because someone else changed the task in the meantime or the workflow
+// does not allow it, is reported with a 500.
+//
+// The store has no transactions, so undoing is done with compensating
+// changes that other clients can observe: a created task is soft-deleted
+// rather than removed, keeping its ID used, and every change and undo is
+// recorded in the history and sent to event streams.
+func rollbackBulk(steps []*bulkStep, results []bulkResult) bool {
+	undone := true
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if step == nil || step.undo == nil {
+			continue
+		}
+		if err := step.undo(); err != nil {
+			log.Printf("bulk: could not roll back operation %d (%s %s): %v", i, step.op.Op, results[i].ID, err)
+			results[i].Status = http.StatusInternalServerError
+			results[i].Body = gin.H{"error": fmt.Sprintf("applied, but could not be rolled back: %v", err)}
+			undone = false
+			continue
+		}
+		results[i].Status = http.StatusFailedDependency
+		results[i].Body = gin.H{"error": "rolled back because another operation failed"}
+	}
+	return undone
+}
+
+// bulkErrorResponse returns the status and body describing an error from
+// applying an operation.
+func bulkErrorResponse(err error) (int, any) {
+	if body, ok := validationErrorBody(err); ok {
+		return http.StatusUnprocessableEntity, body
+	}
+	return storeErrorResponse(err)
+}
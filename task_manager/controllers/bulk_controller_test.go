@@ -0,0 +1,80 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"task_manager/models"
+)
+
+type bulkResponse struct {
+	Results []struct {
+		ID     string `json:"id"`
+		Status int    `json:"status"`
+	} `json:"results"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Error     string `json:"error"`
+}
+
+func TestBulkRollbackOnError(t *testing.T) {
+	srv := newTestServer(t)
+	create := fmt.Sprintf(`{"op":"create","task":%s}`, taskBody("bulk"))
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantItems  []int
+	}{
+		{
+			name:       "independent operations",
+			body:       fmt.Sprintf(`{"operations":[%s,{"op":"delete","id":"missing"}]}`, create),
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusCreated, http.StatusNotFound},
+		},
+		{
+			name:       "invalid operation",
+			body:       fmt.Sprintf(`{"rollback_on_error":true,"operations":[%s,{"op":"rename","id":"1"}]}`, create),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantItems:  []int{http.StatusFailedDependency, http.StatusBadRequest},
+		},
+		{
+			name:       "failed operation",
+			body:       fmt.Sprintf(`{"rollback_on_error":true,"operations":[%s,{"op":"delete","id":"missing"}]}`, create),
+			wantStatus: http.StatusConflict,
+			wantItems:  []int{http.StatusFailedDependency, http.StatusNotFound},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp bulkResponse
+			if status := doJSON(t, http.MethodPost, srv.URL+"/tasks/bulk", tt.body, &resp); status != tt.wantStatus {
+				t.Fatalf("got %d (%s), want %d", status, resp.Error, tt.wantStatus)
+			}
+			if len(resp.Results) != len(tt.wantItems) {
+				t.Fatalf("got %d results, want %d", len(resp.Results), len(tt.wantItems))
+			}
+			for i, want := range tt.wantItems {
+				if got := resp.Results[i].Status; got != want {
+					t.Errorf("result %d: got %d, want %d", i, got, want)
+				}
+			}
+			if tt.wantStatus != http.StatusOK && resp.Error == "" {
+				t.Error("missing top-level error")
+			}
+			if id := resp.Results[0].ID; tt.wantStatus == http.StatusConflict {
+				if status := doJSON(t, http.MethodGet, srv.URL+"/tasks/"+id, "", nil); status != http.StatusNotFound {
+					t.Errorf("GET rolled-back task %s: got %d, want 404", id, status)
+				}
+			}
+		})
+	}
+
+	var tasks []models.Task
+	doJSON(t, http.MethodGet, srv.URL+"/tasks", "", &tasks)
+	if len(tasks) != 1 {
+		t.Errorf("got %d tasks, want only the one from the independent request", len(tasks))
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/models"
+)
+
+// errPreconditionFailed is returned from store callbacks when the task no
+// longer matches the request's If-Match header.
+var errPreconditionFailed = errors.New("task has changed since it was fetched; reload it and retry")
+
+// taskETag is a strong entity tag for a task, derived from its version and
+// the reminder flags, which the reminder scanner sets without a new version:
+// "3" with no flag set, "3-o" when overdue, "3-r" when the due-soon reminder
+// went out and "3-or" with both.
+func taskETag(task models.Task) string {
+	flags := ""
+	if task.Overdue {
+		flags += "o"
+	}
+	if task.ReminderSent {
+		flags += "r"
+	}
+	if flags != "" {
+		flags = "-" + flags
+	}
+	return `"` + strconv.Itoa(task.Version) + flags + `"`
+}
+
+// versionETag is taskETag without the reminder flags.
+func versionETag(task models.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// setTaskETag sets the ETag response header for a single task.
+func setTaskETag(ctx *gin.Context, task models.Task) {
+	ctx.Header("ETag", taskETag(task))
+}
+
+// listETag is a weak entity tag for a page of tasks. It changes whenever a
+// task on the page changes, is added or removed, or the total changes.
+func listETag(tasks []models.Task, total int) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d", total)
+	for _, t := range tasks {
+		fmt.Fprintf(h, "|%s:%s", t.ID, taskETag(t))
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// checkIfMatch returns errPreconditionFailed when the request carries an
+// If-Match header that does not match task. Only versions are compared, so a
+// reminder flag set since the client's read does not fail the request. It is
+// meant to run inside store callbacks, so the check and the write happen
+// atomically.
+func checkIfMatch(ctx *gin.Context, task models.Task) error {
+	header := ctx.GetHeader("If-Match")
+	if header == "" || etagListMatches(stripETagFlags(header), versionETag(task), false) {
+		return nil
+	}
+	return errPreconditionFailed
+}
+
+// stripETagFlags removes the reminder flags taskETag adds from each strong
+// ETag in an If-Match header value.
+func stripETagFlags(header string) string {
+	candidates := strings.Split(header, ",")
+	for i, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if version, _, ok := strings.Cut(candidate, "-"); ok && strings.HasPrefix(candidate, `"`) && strings.HasSuffix(candidate, `"`) {
+			candidate = version + `"`
+		}
+		candidates[i] = candidate
+	}
+	return strings.Join(candidates, ",")
+}
+
+// notModified reports whether the request's If-None-Match header matches etag,
+// in which case the caller should answer 304 Not Modified.
+func notModified(ctx *gin.Context, etag string) bool {
+	header := ctx.GetHeader("If-None-Match")
+	return header != "" && etagListMatches(header, etag, true)
+}
+
+// etagListMatches reports whether a comma-separated If-Match/If-None-Match
+// header value contains etag or "*". Weak comparison ignores W/ prefixes;
+// strong comparison never matches weak tags (RFC 9110, section 8.8.3.2).
+func etagListMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(candidate, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"task_manager/controllers"
+	"task_manager/data"
+	"task_manager/models"
+	"task_manager/router"
+)
+
+// TestETagReflectsReminderFlags checks that a reminder flag, which leaves the
+// version alone, still changes the ETag seen by If-None-Match but not the one
+// compared by If-Match.
+func TestETagReflectsReminderFlags(t *testing.T) {
+	events := data.NewEventBroker(16)
+	t.Cleanup(events.Close)
+	store := data.NewAuditedTaskStore(data.NewEventTaskStore(data.NewInMemoryTaskStore(), events), data.NewInMemoryHistoryStore())
+	tc := controllers.NewTaskController(store, data.NewInMemoryProjectStore(), events, models.DefaultWorkflow())
+	srv := httptest.NewServer(router.InitRoutes(tc))
+	t.Cleanup(srv.Close)
+
+	var created models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("flagged"), &created)
+	get := func(header, value string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/tasks/"+created.ID, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	before := get("", "").Header.Get("ETag")
+	if before != `"1"` {
+		t.Fatalf("got ETag %s, want \"1\"", before)
+	}
+
+	if _, err := store.UpdateReminderFlags(created.ID, func(task *models.Task) error {
+		task.Overdue = true
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp := get("If-None-Match", before)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"1-o"` {
+		t.Errorf("GET after setting overdue: got %d with ETag %s, want 200 with \"1-o\"", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	if resp := get("If-None-Match", `"1-o"`); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET with the flagged ETag: got %d, want 304", resp.StatusCode)
+	}
+
+	// the ETag read before the flag was set, the flagged one, then a stale one
+	for _, tt := range []struct {
+		etag string
+		want int
+	}{{before, http.StatusOK}, {`"2-o"`, http.StatusOK}, {`"2"`, http.StatusPreconditionFailed}} {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+"/tasks/"+created.ID, strings.NewReader(taskBody("renamed")))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", tt.etag)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("PUT with If-Match %s: got %d, want %d", tt.etag, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// keepAliveInterval is how often an idle event stream gets a comment line, so
+// proxies do not time it out.
+const keepAliveInterval = 15 * time.Second
+
+// StreamTasks streams task changes as Server-Sent Events until the client
+// disconnects. Each event is named after its type (task.created, task.updated
+// or task.deleted), carries the event ID and has the event as JSON data.
+// Clients resuming with a Last-Event-ID header first get the retained events
+// they missed, or after a restart every retained event. If a client falls too
+// far behind, the stream ends and it should reconnect.
+func (tc *TaskController) StreamTasks(ctx *gin.Context) {
+	events, cancel, err := tc.events.Subscribe(ctx.GetHeader("Last-Event-ID"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID must be an event ID"})
+		return
+	}
+	defer cancel()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(ctx.Writer, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, payload)
+		case <-keepAlive.C:
+			fmt.Fprint(ctx.Writer, ": keep-alive\n\n")
+		}
+		ctx.Writer.Flush()
+	}
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"task_manager/data"
+	"task_manager/models"
+)
+
+type parentRequest struct {
+	ParentID string `json:"parent_id" binding:"required"`
+}
+
+type blockerRequest struct {
+	TaskID string `json:"task_id" binding:"required"`
+}
+
+// GetSubtasks lists the direct subtasks of a task.
+func (tc *TaskController) GetSubtasks(ctx *gin.Context) {
+	tasks, err := data.Subtasks(tc.store, ctx.Param("id"))
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tasks)
+}
+
+// AddSubtask creates a new task as a subtask of the task in the path.
+func (tc *TaskController) AddSubtask(ctx *gin.Context) {
+	var task models.Task
+	if !bindJSON(ctx, &task) {
+		return
+	}
+	tc.applyDefaults(&task)
+	task.CopyManaged(models.Task{})
+	unlockHierarchy := data.LockHierarchy()
+	defer unlockHierarchy()
+	unlockProjects := data.LockProjectRefs()
+	defer unlockProjects()
+	if err := tc.checkProject(task.ProjectID); err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	counts, unlock, err := tc.lockColumns()
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	defer unlock()
+	if err := tc.checkCreate(task.Status, counts); err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	created, err := data.AddSubtask(tc.storeFor(ctx), ctx.Param("id"), task)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.Header("Location", "/tasks/"+created.ID)
+	setTaskETag(ctx, created)
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// SetParent moves a task under another task.
+func (tc *TaskController) SetParent(ctx *gin.Context) {
+	var req parentRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	unlock := data.LockHierarchy()
+	defer unlock()
+	updated, err := data.SetParent(tc.storeFor(ctx), ctx.Param("id"), req.ParentID)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	setTaskETag(ctx, updated)
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// RemoveParent makes a subtask a top-level task again.
+func (tc *TaskController) RemoveParent(ctx *gin.Context) {
+	unlock := data.LockHierarchy()
+	defer unlock()
+	updated, err := data.SetParent(tc.storeFor(ctx), ctx.Param("id"), "")
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	setTaskETag(ctx, updated)
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// AddBlocker records that the task in the path is blocked by another task.
+func (tc *TaskController) AddBlocker(ctx *gin.Context) {
+	var req blockerRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	unlock := data.LockHierarchy()
+	defer unlock()
+	updated, err := data.AddBlocker(tc.storeFor(ctx), ctx.Param("id"), req.TaskID)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	setTaskETag(ctx, updated)
+	ctx.JSON(http.StatusOK, updated)
+}
+
+func (tc *TaskController) RemoveBlocker(ctx *gin.Context) {
+	unlock := data.LockHierarchy()
+	defer unlock()
+	updated, err := data.RemoveBlocker(tc.storeFor(ctx), ctx.Param("id"), ctx.Param("blockerId"))
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	setTaskETag(ctx, updated)
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// AddChecklistItem appends an item to a task's checklist. Item IDs are
+// assigned by the server and are unique within the task.
+func (tc *TaskController) AddChecklistItem(ctx *gin.Context) {
+	var item models.ChecklistItem
+	if !bindJSON(ctx, &item) {
+		return
+	}
+	updated, err := tc.storeFor(ctx).UpdateTask(ctx.Param("id"), func(task *models.Task) error {
+		item.ID = nextChecklistID(task.Checklist)
+		task.Checklist = append(task.Checklist, item)
+		return nil
+	})
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.Header("Location", "/tasks/"+updated.ID+"/checklist/"+item.ID)
+	ctx.JSON(http.StatusCreated, item)
+}
+
+// PatchChecklistItem applies a JSON Merge Patch to one checklist item,
+// typically {"done": true}.
+func (tc *TaskController) PatchChecklistItem(ctx *gin.Context) {
+	itemID := ctx.Param("itemId")
+	patch, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var item models.ChecklistItem
+	_, err = tc.storeFor(ctx).UpdateTask(ctx.Param("id"), func(task *models.Task) error {
+		i := checklistIndex(task.Checklist, itemID)
+		if i < 0 {
+			return data.ErrChecklistItemNotFound
+		}
+		item = task.Checklist[i]
+		if err := applyMergePatch(&item, patch); err != nil {
+			return err
+		}
+		item.ID = itemID
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			return err
+		}
+		// copy so the stored task's slice is not modified in place
+		task.Checklist = slices.Clone(task.Checklist)
+		task.Checklist[i] = item
+		return nil
+	})
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, item)
+	case errors.Is(err, errInvalidPatch):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case writeValidationError(ctx, err):
+	default:
+		writeStoreError(ctx, err)
+	}
+}
+
+func (tc *TaskController) DeleteChecklistItem(ctx *gin.Context) {
+	itemID := ctx.Param("itemId")
+	_, err := tc.storeFor(ctx).UpdateTask(ctx.Param("id"), func(task *models.Task) error {
+		i := checklistIndex(task.Checklist, itemID)
+		if i < 0 {
+			return data.ErrChecklistItemNotFound
+		}
+		task.Checklist = slices.Delete(slices.Clone(task.Checklist), i, i+1)
+		return nil
+	})
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "checklist item removed"})
+}
+
+func checklistIndex(items []models.ChecklistItem, id string) int {
+	return slices.IndexFunc(items, func(it models.ChecklistItem) bool { return it.ID == id })
+}
+
+// nextChecklistID returns one more than the highest numeric item ID.
+func nextChecklistID(items []models.ChecklistItem) string {
+	max := 0
+	for _, it := range items {
+		if n, err := strconv.Atoi(it.ID); err == nil && n > max {
+			max = n
+		}
+	}
+	return strconv.Itoa(max + 1)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTaskHistory lists every recorded change to a task, oldest first. It also
+// works for deleted tasks.
+func (tc *TaskController) GetTaskHistory(ctx *gin.Context) {
+	id := ctx.Param("id")
+	entries, err := tc.store.History(id)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	// tasks created before history was kept have no entries but still exist
+	if len(entries) == 0 {
+		if _, err := tc.store.GetTaskByID(id); err != nil {
+			writeStoreError(ctx, err)
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// RestoreTask brings back a deleted task, if its project still exists, it
+// leaves no Completed task with open dependencies and its column is not at
+// its WIP limit.
+func (tc *TaskController) RestoreTask(ctx *gin.Context) {
+	restored, err := tc.restoreTask(tc.storeFor(ctx), ctx.Param("id"))
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	setTaskETag(ctx, restored)
+	ctx.JSON(http.StatusOK, restored)
+}
@@ -0,0 +1,51 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"task_manager/models"
+)
+
+func TestRestoreChecksHierarchyAndProject(t *testing.T) {
+	srv := newTestServer(t)
+	transition := func(id string, status models.TaskStatus) {
+		t.Helper()
+		if code := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+id+"/transition", fmt.Sprintf(`{"status":%q}`, status), nil); code != http.StatusOK {
+			t.Fatalf("move %s to %s: got %d, want 200", id, status, code)
+		}
+	}
+
+	// an open subtask cannot come back under a parent completed meanwhile
+	var parent, child models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("parent"), &parent)
+	doJSON(t, http.MethodPost, srv.URL+"/tasks/"+parent.ID+"/subtasks", taskBody("child"), &child)
+	doJSON(t, http.MethodDelete, srv.URL+"/tasks/"+child.ID, "", nil)
+	transition(parent.ID, models.StatusInProgress)
+	transition(parent.ID, models.StatusCompleted)
+	if code := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+child.ID+"/restore", "", nil); code != http.StatusConflict {
+		t.Errorf("restore open subtask of a Completed parent: got %d, want 409", code)
+	}
+
+	// a task cannot come back in a project deleted meanwhile
+	var project models.Project
+	doJSON(t, http.MethodPost, srv.URL+"/projects", `{"name":"gone"}`, &project)
+	var task models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", fmt.Sprintf(`{"title":"in project","due_date":"2030-01-01T00:00:00Z","project_id":%q}`, project.ID), &task)
+	doJSON(t, http.MethodDelete, srv.URL+"/tasks/"+task.ID, "", nil)
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/projects/"+project.ID, "", nil); code != http.StatusOK && code != http.StatusNoContent {
+		t.Fatalf("DELETE project: got %d", code)
+	}
+	if code := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+task.ID+"/restore", "", nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("restore into a deleted project: got %d, want 422", code)
+	}
+
+	// with nothing in the way the restore goes through
+	var free models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("free"), &free)
+	doJSON(t, http.MethodDelete, srv.URL+"/tasks/"+free.ID, "", nil)
+	if code := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+free.ID+"/restore", "", nil); code != http.StatusOK {
+		t.Errorf("restore: got %d, want 200", code)
+	}
+}
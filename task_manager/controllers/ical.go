@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"task_manager/models"
+)
+
+// icalUIDDomain is appended to task IDs to form iCalendar UIDs.
+const icalUIDDomain = "@task-manager"
+
+// icalTime is the RFC 5545 UTC date-time format.
+const icalTime = "20060102T150405Z"
+
+// icalStatuses maps task statuses to VTODO STATUS values.
+var icalStatuses = map[models.TaskStatus]string{
+	models.StatusPending:    "NEEDS-ACTION",
+	models.StatusInProgress: "IN-PROCESS",
+	models.StatusCompleted:  "COMPLETED",
+	models.StatusCancelled:  "CANCELLED",
+}
+
+// icalPriorities maps task priorities to VTODO PRIORITY values (1 highest).
+var icalPriorities = map[models.Priority]int{
+	models.PriorityUrgent: 1,
+	models.PriorityHigh:   3,
+	models.PriorityMedium: 5,
+	models.PriorityLow:    9,
+}
+
+// writeICal writes tasks as an RFC 5545 calendar of VTODO components.
+func writeICal(w io.Writer, tasks []models.Task, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//task_manager//Task Manager//EN",
+	}
+	stamp := now.UTC().Format(icalTime)
+	for _, t := range tasks {
+		lines = append(lines,
+			"BEGIN:VTODO",
+			"UID:"+icalText(t.ID+icalUIDDomain),
+			"DTSTAMP:"+stamp,
+			"SUMMARY:"+icalText(t.Title),
+		)
+		if t.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+icalText(t.Description))
+		}
+		lines = append(lines, "DUE:"+t.DueDate.UTC().Format(icalTime))
+		if status, ok := icalStatuses[t.Status]; ok {
+			lines = append(lines, "STATUS:"+status)
+		}
+		if priority, ok := icalPriorities[t.Priority]; ok {
+			lines = append(lines, fmt.Sprintf("PRIORITY:%d", priority))
+		}
+		if len(t.Labels) > 0 {
+			categories := make([]string, len(t.Labels))
+			for i, l := range t.Labels {
+				categories[i] = icalText(l)
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		if t.Recurrence != nil {
+			lines = append(lines, "RRULE:"+t.Recurrence.String())
+		}
+		if t.ParentID != "" {
+			lines = append(lines, "RELATED-TO:"+icalText(t.ParentID+icalUIDDomain))
+		}
+		if t.Version > 0 {
+			lines = append(lines, fmt.Sprintf("SEQUENCE:%d", t.Version-1))
+		}
+		lines = append(lines, "END:VTODO")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldICalLine(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// icalText escapes a TEXT value (RFC 5545, section 3.3.11).
+func icalText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// foldICalLine splits a content line into lines of at most 75 octets, each
+// continuation starting with a space, without breaking UTF-8 sequences.
+func foldICalLine(line string) string {
+	const limit = 75
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		width = limit - 1 // the leading space counts
+	}
+	b.WriteString(line)
+	return b.String()
+}
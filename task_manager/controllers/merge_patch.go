@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// errInvalidPatch wraps every error caused by the patch body itself, as
+// opposed to errors from the store.
+var errInvalidPatch = errors.New("invalid merge patch")
+
+// applyMergePatch applies an RFC 7386 JSON Merge Patch to the JSON encoding
+// of v (a non-nil pointer) and decodes the result back into v. Members set to null in the patch
+// are removed, so the corresponding field in v ends up at its zero value.
+func applyMergePatch(v any, patch []byte) error {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPatch, err)
+	}
+	if _, ok := p.(map[string]any); !ok {
+		return fmt.Errorf("%w: body must be a JSON object", errInvalidPatch)
+	}
+
+	current, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, p))
+	if err != nil {
+		return err
+	}
+	// zero v first so members removed by the patch don't keep their old values
+	reflect.ValueOf(v).Elem().SetZero()
+	if err := json.Unmarshal(merged, v); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPatch, err)
+	}
+	return nil
+}
+
+// mergePatch implements the MergePatch function from RFC 7386 section 2.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+		} else {
+			t[name] = mergePatch(t[name], value)
+		}
+	}
+	return t
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/data"
+	"task_manager/models"
+)
+
+// errUnknownProject is returned when a task names a project that does not
+// exist.
+var errUnknownProject = errors.New("does not match any project")
+
+// ProjectStats counts the tasks of one project by status. Tasks without a
+// project are counted under an empty ProjectID.
+type ProjectStats struct {
+	ProjectID string                    `json:"project_id"`
+	Name      string                    `json:"name"`
+	Total     int                       `json:"total"`
+	ByStatus  map[models.TaskStatus]int `json:"by_status"`
+}
+
+func (tc *TaskController) GetProjects(ctx *gin.Context) {
+	projects, err := tc.projects.GetProjects()
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, projects)
+}
+
+func (tc *TaskController) GetProjectByID(ctx *gin.Context) {
+	project, err := tc.projects.GetProjectByID(ctx.Param("id"))
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, project)
+}
+
+func (tc *TaskController) AddProject(ctx *gin.Context) {
+	var project models.Project
+	if !bindJSON(ctx, &project) {
+		return
+	}
+	created, err := tc.projects.AddProject(project)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.Header("Location", "/projects/"+created.ID)
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// UpdateProject replaces a project's name and description.
+func (tc *TaskController) UpdateProject(ctx *gin.Context) {
+	var project models.Project
+	if !bindJSON(ctx, &project) {
+		return
+	}
+	project.ID = ctx.Param("id")
+	updated, err := tc.projects.UpdateProject(project)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteProject deletes a project that no longer has tasks.
+func (tc *TaskController) DeleteProject(ctx *gin.Context) {
+	if err := data.RemoveProject(tc.projects, tc.store, ctx.Param("id")); err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "project removed"})
+}
+
+// GetTaskStats counts tasks per project and status. It takes the same filters
+// as GET /tasks. Every project is listed, even without tasks, followed by the
+// tasks that have no project, if any.
+func (tc *TaskController) GetTaskStats(ctx *gin.Context) {
+	q, err := parseTaskQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	counts, err := tc.store.CountTasks(q)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	projects, err := tc.projects.GetProjects()
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+
+	stats := []*ProjectStats{}
+	byProject := map[string]*ProjectStats{}
+	add := func(id, name string) *ProjectStats {
+		s := &ProjectStats{ProjectID: id, Name: name, ByStatus: map[models.TaskStatus]int{}}
+		for _, status := range models.TaskStatuses {
+			s.ByStatus[status] = 0
+		}
+		stats = append(stats, s)
+		byProject[id] = s
+		return s
+	}
+	for _, p := range projects {
+		if q.ProjectID == "" || p.ID == q.ProjectID {
+			add(p.ID, p.Name)
+		}
+	}
+	for _, c := range counts {
+		s, ok := byProject[c.ProjectID]
+		if !ok {
+			s = add(c.ProjectID, "")
+		}
+		s.ByStatus[c.Status] += c.Count
+		s.Total += c.Count
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
+
+// checkProject returns errUnknownProject unless id is empty or names a
+// project.
+func (tc *TaskController) checkProject(id string) error {
+	if id == "" {
+		return nil
+	}
+	_, err := tc.projects.GetProjectByID(id)
+	if errors.Is(err, data.ErrProjectNotFound) {
+		return errUnknownProject
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPreviewSize = 10
+	maxPreviewSize     = 100
+)
+
+// Occurrence is one upcoming instance of a recurring task.
+type Occurrence struct {
+	Occurrence int       `json:"occurrence"`
+	DueDate    time.Time `json:"due_date"`
+}
+
+// GetOccurrences previews the occurrences that will follow a recurring task,
+// without creating them. The list is empty for tasks that do not recur.
+func (tc *TaskController) GetOccurrences(ctx *gin.Context) {
+	limit := defaultPreviewSize
+	if s := ctx.Query("limit"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxPreviewSize {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPreviewSize)})
+			return
+		}
+	}
+	task, err := tc.store.GetTaskByID(ctx.Param("id"))
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+
+	occurrences := []Occurrence{}
+	if task.Recurrence != nil {
+		n := max(task.Occurrence, 1)
+		for i, due := range task.Recurrence.Upcoming(task.DueDate, n, limit) {
+			occurrences = append(occurrences, Occurrence{Occurrence: n + i + 1, DueDate: due})
+		}
+	}
+	ctx.JSON(http.StatusOK, occurrences)
+}
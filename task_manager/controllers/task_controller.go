@@ -1,56 +1,408 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"task_manager/data"
 	"task_manager/models"
 )
 
-func GetTasks(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, data.GetAllTasks())
+// ActorKey is the gin context key holding the user a request acts for in the
+// task history. Authentication middleware sets it; requests without it are
+// recorded as AnonymousActor.
+const (
+	ActorKey       = "actor"
+	AnonymousActor = "anonymous"
+)
+
+// ActorHeader is the request header read by ActorFromHeader.
+const ActorHeader = "X-User"
+
+// ActorFromHeader sets the actor from the X-User header. Any client can send
+// that header, so only use it behind a proxy that authenticates users and
+// sets or strips X-User itself.
+func ActorFromHeader() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if actor := strings.TrimSpace(ctx.GetHeader(ActorHeader)); actor != "" {
+			ctx.Set(ActorKey, actor)
+		}
+		ctx.Next()
+	}
+}
+
+// TaskController serves the task and project endpoints from an audited
+// TaskStore and a ProjectStore, streams the changes published to events and
+// moves tasks between statuses as workflow allows.
+type TaskController struct {
+	store    *data.AuditedTaskStore
+	projects data.ProjectStore
+	events   *data.EventBroker
+	workflow models.Workflow
+	// columnMu serializes changes that may fill a column with a WIP limit.
+	columnMu sync.Mutex
+}
+
+// NewTaskController returns a TaskController backed by store, projects and
+// events, enforcing workflow.
+func NewTaskController(store *data.AuditedTaskStore, projects data.ProjectStore, events *data.EventBroker, workflow models.Workflow) *TaskController {
+	return &TaskController{store: store, projects: projects, events: events, workflow: workflow}
+}
+
+// storeFor returns the store acting for the request's user, so that changes
+// are attributed to them.
+func (tc *TaskController) storeFor(ctx *gin.Context) *data.AuditedTaskStore {
+	actor := ctx.GetString(ActorKey)
+	if actor == "" {
+		actor = AnonymousActor
+	}
+	return tc.store.As(actor)
 }
 
-func GetTaskByID(ctx *gin.Context) {
+// GetTasks lists tasks, optionally filtered, sorted and paginated.
+// The total number of matching tasks is returned in X-Total-Count. The list
+// carries a weak ETag, so pollers can send If-None-Match and get a 304.
+func (tc *TaskController) GetTasks(ctx *gin.Context) {
+	q, err := parseTaskQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, total, err := tc.store.FindTasks(q)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	setPaginationHeaders(ctx, q, total)
+	etag := listETag(tasks, total)
+	ctx.Header("ETag", etag)
+	if notModified(ctx, etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.JSON(http.StatusOK, tasks)
+}
+
+// GetTaskByID returns a task with its ETag, or 304 Not Modified when the
+// request's If-None-Match already names the current version.
+func (tc *TaskController) GetTaskByID(ctx *gin.Context) {
 	id := ctx.Param("id")
-	task, err := data.GetTaskByID(id)
+	task, err := tc.store.GetTaskByID(id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		writeStoreError(ctx, err)
+		return
+	}
+	setTaskETag(ctx, *task)
+	if notModified(ctx, taskETag(*task)) {
+		ctx.Status(http.StatusNotModified)
 		return
 	}
 	ctx.JSON(http.StatusOK, task)
 }
 
-func AddTask(ctx *gin.Context) {
+func (tc *TaskController) AddTask(ctx *gin.Context) {
 	var task models.Task
-	if err := ctx.ShouldBindJSON(&task); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(ctx, &task) {
 		return
 	}
-	data.AddTask(task)
-	ctx.JSON(http.StatusCreated, gin.H{"message": "task created"})
+	created, err := tc.createTask(tc.storeFor(ctx), task)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	ctx.Header("Location", "/tasks/"+created.ID)
+	setTaskETag(ctx, created)
+	ctx.JSON(http.StatusCreated, created)
 }
 
-func UpdateTask(ctx *gin.Context) {
+// UpdateTask replaces a task with the request body (PUT semantics).
+// Completing a recurring task creates its next occurrence. An If-Match header
+// makes the update conditional on the task's current ETag.
+func (tc *TaskController) UpdateTask(ctx *gin.Context) {
 	id := ctx.Param("id")
-	var task models.Task
-	if err := ctx.ShouldBindJSON(&task); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var replacement models.Task
+	if !bindJSON(ctx, &replacement) {
 		return
 	}
-	if err := data.UpdateTask(id, task); err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	updated, err := tc.replaceTask(tc.storeFor(ctx), id, replacement, func(task models.Task) error {
+		return checkIfMatch(ctx, task)
+	})
+	if err != nil {
+		writeStoreError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "task updated"})
+	setTaskETag(ctx, updated)
+	ctx.JSON(http.StatusOK, updated)
 }
 
-func DeleteTask(ctx *gin.Context) {
+// PatchTask applies a JSON Merge Patch (RFC 7386) to a task. Members set to
+// null are cleared; the result must still pass task validation. Completing a
+// recurring task creates its next occurrence. If-Match works as for PUT.
+func (tc *TaskController) PatchTask(ctx *gin.Context) {
 	id := ctx.Param("id")
-	if err := data.DeleteTask(id); err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	patch, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := tc.changeTask(tc.storeFor(ctx), id, func(task *models.Task) error {
+		stored := *task
+		if err := checkIfMatch(ctx, stored); err != nil {
+			return err
+		}
+		if err := applyMergePatch(task, patch); err != nil {
+			return err
+		}
+		task.CopyManaged(stored)
+		if err := binding.Validator.ValidateStruct(task); err != nil {
+			return err
+		}
+		task.ApplyDefaults()
+		if task.ProjectID != stored.ProjectID {
+			return tc.checkProject(task.ProjectID)
+		}
+		return nil
+	})
+	switch {
+	case err == nil:
+		setTaskETag(ctx, updated)
+		ctx.JSON(http.StatusOK, updated)
+	case errors.Is(err, errInvalidPatch):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case writeValidationError(ctx, err):
+	default:
+		writeStoreError(ctx, err)
+	}
+}
+
+// DeleteTask soft-deletes a task. If-Match works as for PUT.
+func (tc *TaskController) DeleteTask(ctx *gin.Context) {
+	id := ctx.Param("id")
+	err := tc.storeFor(ctx).DeleteTask(id, func(task models.Task) error {
+		return checkIfMatch(ctx, task)
+	})
+	if err != nil {
+		writeStoreError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "task removed"})
 }
+
+// createTask stores a new task from a validated request body, filling in the
+// defaults and clearing the server-managed fields. It must start in the
+// workflow's initial status.
+func (tc *TaskController) createTask(store data.TaskStore, task models.Task) (models.Task, error) {
+	tc.applyDefaults(&task)
+	task.CopyManaged(models.Task{})
+	if task.Recurrence != nil {
+		task.Occurrence = 1
+	}
+	unlockProjects := data.LockProjectRefs()
+	defer unlockProjects()
+	if err := tc.checkProject(task.ProjectID); err != nil {
+		return models.Task{}, err
+	}
+	counts, unlock, err := tc.lockColumns()
+	if err != nil {
+		return models.Task{}, err
+	}
+	defer unlock()
+	if err := tc.checkCreate(task.Status, counts); err != nil {
+		return models.Task{}, err
+	}
+	return store.AddTask(task)
+}
+
+// replaceTask replaces task id with a validated request body, keeping its
+// server-managed fields. If check is not nil it is called with the stored task
+// first, atomically with the update, and an error from it cancels the update.
+func (tc *TaskController) replaceTask(store data.TaskStore, id string, replacement models.Task, check func(task models.Task) error) (models.Task, error) {
+	replacement.ApplyDefaults()
+	return tc.changeTask(store, id, func(task *models.Task) error {
+		if check != nil {
+			if err := check(*task); err != nil {
+				return err
+			}
+		}
+		if err := tc.checkProject(replacement.ProjectID); err != nil {
+			return err
+		}
+		replacement.CopyManaged(*task)
+		*task = replacement
+		return nil
+	})
+}
+
+// changeTask applies change to task id atomically. A status change must be
+// allowed by the workflow and fit the target column's WIP limit; into
+// Completed it must find no open dependencies, and back to an open status it
+// must not leave a Completed task with an open subtask or blocker. Completing
+// a recurring task then schedules its next occurrence, which must fit the
+// initial column's WIP limit too.
+//
+// Most changes keep the status and project and are written under the store's
+// own locking alone. A change to either is retried under the locks its checks
+// need: see changeLocks.
+func (tc *TaskController) changeTask(store data.TaskStore, id string, change func(task *models.Task) error) (models.Task, error) {
+	var locks changeLocks
+	for {
+		updated, err := tc.changeLocked(store, id, change, locks)
+		var more *changeLocks
+		if !errors.As(err, &more) {
+			return updated, err
+		}
+		locks.status = locks.status || more.status
+		locks.project = locks.project || more.project
+	}
+}
+
+// changeLocks says which process-wide locks a task change holds, and as an
+// error which ones it needs but does not hold.
+type changeLocks struct {
+	// status is the hierarchy, project and column locks: the dependency checks
+	// and WIP limits of a status change only hold while they are held.
+	status bool
+	// project is the project lock alone, so no project can be removed between
+	// checking the task's new project and writing it.
+	project bool
+}
+
+func (l *changeLocks) Error() string {
+	return "task change needs more locks"
+}
+
+// changeLocked is one attempt of changeTask holding locks. It returns a
+// *changeLocks error, without writing, if change turns out to need more.
+func (tc *TaskController) changeLocked(store data.TaskStore, id string, change func(task *models.Task) error, locks changeLocks) (models.Task, error) {
+	if locks.status {
+		unlockHierarchy := data.LockHierarchy()
+		defer unlockHierarchy()
+	}
+	if locks.status || locks.project {
+		unlockProjects := data.LockProjectRefs()
+		defer unlockProjects()
+	}
+	var completeErr, reopenErr error
+	var counts map[models.TaskStatus]int
+	if locks.status {
+		var err error
+		completeErr, reopenErr, err = data.CheckStatusChange(tc.store, id)
+		if err != nil {
+			return models.Task{}, err
+		}
+		var unlock func()
+		if counts, unlock, err = tc.lockColumns(); err != nil {
+			return models.Task{}, err
+		}
+		defer unlock()
+	}
+
+	var completed bool
+	updated, err := store.UpdateTask(id, func(task *models.Task) error {
+		stored := *task
+		if err := change(task); err != nil {
+			return err
+		}
+		need := changeLocks{
+			status:  task.Status != stored.Status && !locks.status,
+			project: task.ProjectID != stored.ProjectID && !locks.status && !locks.project,
+		}
+		if need.status || need.project {
+			return &need
+		}
+		if task.Status == stored.Status {
+			return nil
+		}
+		if err := tc.checkMove(stored.Status, task.Status, counts); err != nil {
+			return err
+		}
+		if err := checkCompletion(stored, task.Status, completeErr); err != nil {
+			return err
+		}
+		if !stored.Status.Open() && task.Status.Open() && reopenErr != nil {
+			return reopenErr
+		}
+		completed = isCompletion(stored, task.Status)
+		if completed && data.HasNextOccurrence(*task) {
+			return tc.checkNextOccurrence(stored.Status, task.Status, counts)
+		}
+		return nil
+	})
+	if err == nil && completed {
+		updated, err = tc.scheduleNext(store, updated)
+	}
+	return updated, err
+}
+
+// isCompletion reports whether moving current to next completes the task.
+func isCompletion(current models.Task, next models.TaskStatus) bool {
+	return next == models.StatusCompleted && current.Status != models.StatusCompleted
+}
+
+// checkCompletion rejects moving a task into Completed with completeErr, the
+// error data.CheckStatusChange found for completing it, if not nil.
+func checkCompletion(current models.Task, next models.TaskStatus, completeErr error) error {
+	if completeErr != nil && isCompletion(current, next) {
+		return completeErr
+	}
+	return nil
+}
+
+// scheduleNext creates the next occurrence of a task that was just completed,
+// if it recurs, in the initial status and returns the task with NextID filled
+// in.
+func (tc *TaskController) scheduleNext(store data.TaskStore, task models.Task) (models.Task, error) {
+	linked, err := data.ScheduleNextOccurrence(store, task.ID, tc.workflow.InitialStatus())
+	if err != nil || linked == nil {
+		return task, err
+	}
+	return *linked, nil
+}
+
+// writeStoreError maps a TaskStore error to an HTTP response.
+func writeStoreError(ctx *gin.Context, err error) {
+	ctx.JSON(storeErrorResponse(err))
+}
+
+// storeErrorResponse returns the status and body describing a TaskStore
+// error.
+func storeErrorResponse(err error) (int, gin.H) {
+	var open *data.OpenDependenciesError
+	var transition *models.TransitionError
+	var wip *models.WIPLimitError
+	var initial *models.InitialStatusError
+	switch {
+	case errors.Is(err, data.ErrTaskNotFound), errors.Is(err, data.ErrChecklistItemNotFound),
+		errors.Is(err, data.ErrProjectNotFound):
+		return http.StatusNotFound, gin.H{"message": err.Error()}
+	case errors.Is(err, errPreconditionFailed):
+		return http.StatusPreconditionFailed, gin.H{"error": err.Error()}
+	case errors.Is(err, data.ErrDuplicateTaskID), errors.Is(err, data.ErrUpdateConflict),
+		errors.Is(err, data.ErrSelfReference), errors.Is(err, data.ErrHierarchyCycle),
+		errors.Is(err, data.ErrProjectInUse), errors.Is(err, data.ErrCompletedDependent):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	case errors.As(err, &open):
+		return http.StatusConflict, gin.H{"error": err.Error(), "blockers": open.Blockers, "subtasks": open.Subtasks}
+	case errors.As(err, &transition):
+		return http.StatusConflict, gin.H{"error": err.Error(), "from": transition.From, "to": transition.To, "allowed": transition.Allowed}
+	case errors.As(err, &wip):
+		return http.StatusConflict, gin.H{"error": err.Error(), "status": wip.Status, "wip_limit": wip.Limit}
+	case errors.As(err, &initial):
+		return http.StatusUnprocessableEntity, gin.H{
+			"error":  "validation failed",
+			"fields": []FieldError{{Field: "status", Message: err.Error()}},
+		}
+	case errors.Is(err, errUnknownProject):
+		return http.StatusUnprocessableEntity, gin.H{
+			"error":  "validation failed",
+			"fields": []FieldError{{Field: "project_id", Message: err.Error()}},
+		}
+	default:
+		return http.StatusInternalServerError, gin.H{"error": err.Error()}
+	}
+}
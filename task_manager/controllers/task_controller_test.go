@@ -0,0 +1,176 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/controllers"
+	"task_manager/data"
+	"task_manager/models"
+	"task_manager/router"
+)
+
+// newTestServer serves the API from an empty in-memory store, wired the way
+// main does it.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return newWorkflowServer(t, models.DefaultWorkflow())
+}
+
+// newWorkflowServer is newTestServer enforcing workflow.
+func newWorkflowServer(t *testing.T, workflow models.Workflow) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	events := data.NewEventBroker(16)
+	t.Cleanup(events.Close)
+	store := data.NewAuditedTaskStore(data.NewEventTaskStore(data.NewInMemoryTaskStore(), events), data.NewInMemoryHistoryStore())
+	tc := controllers.NewTaskController(store, data.NewInMemoryProjectStore(), events, workflow)
+	srv := httptest.NewServer(router.InitRoutes(tc))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func doJSON(t *testing.T, method, url, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Errorf("%s %s: decoding response: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func taskBody(title string) string {
+	return fmt.Sprintf(`{"title":%q,"due_date":"2030-01-01T00:00:00Z"}`, title)
+}
+
+// TestConcurrentRequests fires creates, reads, updates and deletes at the
+// API from many goroutines at once. Run it with -race.
+func TestConcurrentRequests(t *testing.T) {
+	srv := newTestServer(t)
+	const workers = 16
+	const perWorker = 10
+
+	var wg sync.WaitGroup
+	ids := make(chan string, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				var created models.Task
+				if status := doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody(fmt.Sprintf("task %d-%d", w, i)), &created); status != http.StatusCreated {
+					t.Errorf("POST /tasks: got %d, want 201", status)
+					continue
+				}
+				ids <- created.ID
+
+				if status := doJSON(t, http.MethodGet, srv.URL+"/tasks?limit=5", "", nil); status != http.StatusOK {
+					t.Errorf("GET /tasks: got %d, want 200", status)
+				}
+				if status := doJSON(t, http.MethodPut, srv.URL+"/tasks/"+created.ID, taskBody("renamed"), nil); status != http.StatusOK {
+					t.Errorf("PUT /tasks/%s: got %d, want 200", created.ID, status)
+				}
+				if i%2 == 0 {
+					if status := doJSON(t, http.MethodDelete, srv.URL+"/tasks/"+created.ID, "", nil); status != http.StatusOK {
+						t.Errorf("DELETE /tasks/%s: got %d, want 200", created.ID, status)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[string]bool{}
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("ID %s was handed out twice", id)
+		}
+		seen[id] = true
+	}
+	var tasks []models.Task
+	doJSON(t, http.MethodGet, srv.URL+"/tasks", "", &tasks)
+	if want := workers * perWorker / 2; len(tasks) != want {
+		t.Errorf("got %d tasks left, want %d", len(tasks), want)
+	}
+	for _, task := range tasks {
+		if task.Title != "renamed" || task.Version != 2 {
+			t.Errorf("task %s: got title %q version %d, want %q version 2", task.ID, task.Title, task.Version, "renamed")
+		}
+	}
+}
+
+// TestConcurrentUpdatesOfOneTask checks that concurrent PUTs of the same task
+// are applied one at a time: every update is counted in the version.
+func TestConcurrentUpdatesOfOneTask(t *testing.T) {
+	srv := newTestServer(t)
+	var created models.Task
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("shared"), &created); status != http.StatusCreated {
+		t.Fatalf("POST /tasks: got %d, want 201", status)
+	}
+
+	const updates = 50
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if status := doJSON(t, http.MethodPut, srv.URL+"/tasks/"+created.ID, taskBody(fmt.Sprintf("update %d", i)), nil); status != http.StatusOK {
+				t.Errorf("PUT: got %d, want 200", status)
+			}
+			doJSON(t, http.MethodGet, srv.URL+"/tasks/"+created.ID, "", nil)
+		}(i)
+	}
+	wg.Wait()
+
+	var task models.Task
+	doJSON(t, http.MethodGet, srv.URL+"/tasks/"+created.ID, "", &task)
+	if task.Version != updates+1 {
+		t.Errorf("got version %d, want %d", task.Version, updates+1)
+	}
+}
+
+// TestUpdateWithoutStatusChangeSkipsHierarchyLock checks that an update that
+// keeps the status does not wait for the hierarchy lock, which only status
+// changes need.
+func TestUpdateWithoutStatusChangeSkipsHierarchyLock(t *testing.T) {
+	srv := newTestServer(t)
+	var created models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("busy"), &created)
+
+	unlock := data.LockHierarchy()
+	done := make(chan int, 1)
+	go func() {
+		done <- doJSON(t, http.MethodPut, srv.URL+"/tasks/"+created.ID, taskBody("renamed"), nil)
+	}()
+	select {
+	case status := <-done:
+		unlock()
+		if status != http.StatusOK {
+			t.Errorf("PUT: got %d, want 200", status)
+		}
+	case <-time.After(5 * time.Second):
+		unlock()
+		t.Fatal("PUT waited for the hierarchy lock")
+	}
+}
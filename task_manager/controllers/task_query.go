@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/data"
+	"task_manager/models"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parseTaskQuery reads the GET /tasks query parameters:
+// status, due_before, due_after, q, parent_id, project_id, label (repeatable or
+// comma-separated), priority, sort, page and limit.
+// Pagination is only applied when page or limit is given.
+func parseTaskQuery(ctx *gin.Context) (data.TaskQuery, error) {
+	var q data.TaskQuery
+
+	if s := ctx.Query("status"); s != "" {
+		q.Status = models.TaskStatus(s)
+		if !q.Status.Valid() {
+			return q, fmt.Errorf("invalid status %q", s)
+		}
+	}
+
+	var err error
+	if q.DueBefore, err = parseTimeParam(ctx, "due_before"); err != nil {
+		return q, err
+	}
+	if q.DueAfter, err = parseTimeParam(ctx, "due_after"); err != nil {
+		return q, err
+	}
+	q.Search = strings.TrimSpace(ctx.Query("q"))
+	q.ParentID = ctx.Query("parent_id")
+	q.ProjectID = ctx.Query("project_id")
+	for _, s := range ctx.QueryArray("label") {
+		q.Labels = append(q.Labels, strings.Split(s, ",")...)
+	}
+	q.Labels = models.NormalizeLabels(q.Labels)
+	if s := ctx.Query("priority"); s != "" {
+		q.Priority = models.Priority(s)
+		if !q.Priority.Valid() {
+			return q, fmt.Errorf("invalid priority %q", s)
+		}
+	}
+
+	if s := ctx.Query("sort"); s != "" {
+		for _, key := range strings.Split(s, ",") {
+			field := data.SortField{Field: strings.TrimPrefix(key, "-"), Desc: strings.HasPrefix(key, "-")}
+			if !slices.Contains(data.SortFields, field.Field) {
+				return q, fmt.Errorf("cannot sort by %q (allowed: %s)", field.Field, strings.Join(data.SortFields, ", "))
+			}
+			q.Sort = append(q.Sort, field)
+		}
+	}
+
+	pageStr, limitStr := ctx.Query("page"), ctx.Query("limit")
+	if pageStr == "" && limitStr == "" {
+		return q, nil
+	}
+	page, limit := 1, defaultPageSize
+	if pageStr != "" {
+		if page, err = strconv.Atoi(pageStr); err != nil || page < 1 {
+			return q, fmt.Errorf("page must be a positive integer")
+		}
+	}
+	if limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 || limit > maxPageSize {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+	}
+	if page > math.MaxInt/limit {
+		return q, fmt.Errorf("page must be at most %d", math.MaxInt/limit)
+	}
+	q.Offset = (page - 1) * limit
+	q.Limit = limit
+	return q, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or plain YYYY-MM-DD dates (UTC midnight).
+func parseTimeParam(ctx *gin.Context, name string) (time.Time, error) {
+	s := ctx.Query(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", name)
+}
+
+// setPaginationHeaders sets X-Total-Count and, for paged queries, an RFC 8288
+// Link header with first, prev, next and last page URLs.
+func setPaginationHeaders(ctx *gin.Context, q data.TaskQuery, total int) {
+	ctx.Header("X-Total-Count", strconv.Itoa(total))
+	if q.Limit == 0 {
+		return
+	}
+
+	page := q.Offset/q.Limit + 1
+	last := (total + q.Limit - 1) / q.Limit
+	if last < 1 {
+		last = 1
+	}
+	link := func(p int, rel string) string {
+		u := url.URL{Path: ctx.Request.URL.Path}
+		params := ctx.Request.URL.Query()
+		params.Set("page", strconv.Itoa(p))
+		params.Set("limit", strconv.Itoa(q.Limit))
+		u.RawQuery = params.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(min(page-1, last), "prev"))
+	}
+	if page < last {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	ctx.Header("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,431 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"task_manager/data"
+	"task_manager/models"
+)
+
+const (
+	// maxImportBodyBytes bounds the size of a POST /tasks/import body.
+	maxImportBodyBytes = 10 << 20
+	// maxImportRows bounds the number of tasks in one import.
+	maxImportRows = 1000
+)
+
+// csvColumns are the CSV columns written by export and understood by import,
+// named after the task's JSON fields. Labels are separated by csvLabelSep and
+// recurrence is an RRULE.
+var csvColumns = []string{"id", "title", "description", "due_date", "status", "priority", "project_id", "labels", "recurrence"}
+
+const csvLabelSep = ";"
+
+// csvEscapes are the leading characters that make spreadsheet apps read a
+// cell as a formula. writeCSV prefixes cells starting with one of them, or
+// with the prefix itself, with csvEscapePrefix, and import strips it again.
+const (
+	csvEscapes      = "=+-@\t\r'"
+	csvEscapePrefix = "'"
+)
+
+// Import modes, chosen by the mode parameter.
+const (
+	// importCreate creates every row; rows whose id is in use fail.
+	importCreate = "create"
+	// importUpsert replaces the tasks whose id is in use and creates the rest.
+	importUpsert = "upsert"
+	// importNewIDs creates every row under a new ID, ignoring its id.
+	importNewIDs = "new_ids"
+)
+
+// importRecord is one decoded task of an import, or the status and body
+// describing why it could not be decoded.
+type importRecord struct {
+	task   models.Task
+	status int
+	body   any
+}
+
+// importResult reports the outcome for one imported task. Rows count from 1,
+// not counting the CSV header.
+type importResult struct {
+	Row    int    `json:"row"`
+	ID     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Body   any    `json:"body,omitempty"`
+}
+
+// ExportTasks downloads the tasks matching the GET /tasks filters as JSON,
+// CSV or an iCalendar file of VTODOs, chosen by the format parameter. Paging
+// parameters are ignored.
+func (tc *TaskController) ExportTasks(ctx *gin.Context) {
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "ics" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of: json, csv, ics"})
+		return
+	}
+	q, err := parseTaskQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q.Offset, q.Limit = 0, 0
+	tasks, _, err := tc.store.FindTasks(q)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+	switch format {
+	case "json":
+		ctx.JSON(http.StatusOK, tasks)
+	case "csv":
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Status(http.StatusOK)
+		_ = writeCSV(ctx.Writer, tasks)
+	case "ics":
+		ctx.Header("Content-Type", "text/calendar; charset=utf-8")
+		ctx.Status(http.StatusOK)
+		_ = writeICal(ctx.Writer, tasks, time.Now())
+	}
+}
+
+// ImportTasks creates tasks from a JSON array of tasks or a CSV file with the
+// export columns, and reports the outcome for every row. Rows are imported
+// on their own: invalid rows are reported and skipped. The mode parameter
+// decides what happens to rows whose id is already in use. With dry_run=true
+// rows are only checked. The format comes from the format parameter or the
+// Content-Type (application/json or text/csv).
+func (tc *TaskController) ImportTasks(ctx *gin.Context) {
+	format := ctx.Query("format")
+	if format == "" {
+		switch ctx.ContentType() {
+		case "application/json":
+			format = "json"
+		case "text/csv":
+			format = "csv"
+		}
+	}
+	mode := ctx.DefaultQuery("mode", importCreate)
+	if mode != importCreate && mode != importUpsert && mode != importNewIDs {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "mode must be one of: create, upsert, new_ids"})
+		return
+	}
+	dryRun := ctx.Query("dry_run") == "true"
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBodyBytes)
+	var records []importRecord
+	var err error
+	switch format {
+	case "json":
+		records, err = readImportJSON(body)
+	case "csv":
+		records, err = readImportCSV(body)
+	default:
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "send application/json or text/csv, or set format to json or csv"})
+		return
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit)})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case len(records) > maxImportRows:
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("at most %d tasks can be imported at once", maxImportRows)})
+		return
+	}
+
+	store := tc.storeFor(ctx)
+	var counts map[models.TaskStatus]int
+	if dryRun {
+		if counts, err = tc.columnCounts(); err != nil {
+			writeStoreError(ctx, err)
+			return
+		}
+	}
+	results := make([]importResult, len(records))
+	succeeded := 0
+	for i, rec := range records {
+		if mode == importNewIDs {
+			rec.task.ID = ""
+		}
+		results[i] = importResult{Row: i + 1, ID: rec.task.ID}
+		if rec.status != 0 {
+			results[i].Status, results[i].Body = rec.status, rec.body
+			continue
+		}
+		var task models.Task
+		var created bool
+		if dryRun {
+			task, created, err = tc.checkImport(rec.task, mode == importUpsert, counts)
+		} else {
+			task, created, err = tc.importTask(store, rec.task, mode == importUpsert)
+		}
+		if err != nil {
+			results[i].Status, results[i].Body = bulkErrorResponse(err)
+			continue
+		}
+		succeeded++
+		results[i].ID, results[i].Status = task.ID, http.StatusOK
+		if created && !dryRun {
+			results[i].Status = http.StatusCreated
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"mode":      mode,
+		"dry_run":   dryRun,
+		"rows":      results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// importTask validates one imported task and creates it, or with upsert
+// replaces the task with its ID like PUT /tasks/:id if there is one. It
+// reports whether the task was created.
+func (tc *TaskController) importTask(store data.TaskStore, task models.Task, upsert bool) (models.Task, bool, error) {
+	if err := binding.Validator.ValidateStruct(&task); err != nil {
+		return models.Task{}, false, err
+	}
+	if upsert && task.ID != "" {
+		replaced, err := tc.replaceTask(store, task.ID, task, nil)
+		if !errors.Is(err, data.ErrTaskNotFound) {
+			return replaced, false, err
+		}
+	}
+	created, err := tc.createTask(store, task)
+	return created, true, err
+}
+
+// checkImport runs the checks importTask would for one task without writing
+// anything, and reports whether the task would be created. counts holds the
+// number of tasks in each status once the rows checked so far are imported,
+// and is updated for this one, so WIP limits are checked for the whole file.
+// Only IDs of deleted tasks are not checked: they are found by a real import.
+func (tc *TaskController) checkImport(task models.Task, upsert bool, counts map[models.TaskStatus]int) (models.Task, bool, error) {
+	if err := binding.Validator.ValidateStruct(&task); err != nil {
+		return models.Task{}, false, err
+	}
+	var stored *models.Task
+	if task.ID != "" {
+		found, err := tc.store.GetTaskByID(task.ID)
+		switch {
+		case err == nil && !upsert:
+			return models.Task{}, false, data.ErrDuplicateTaskID
+		case err == nil:
+			stored = found
+		case !errors.Is(err, data.ErrTaskNotFound):
+			return models.Task{}, false, err
+		}
+	}
+	if err := tc.checkProject(task.ProjectID); err != nil {
+		return models.Task{}, false, err
+	}
+
+	if stored == nil {
+		tc.applyDefaults(&task)
+		if err := tc.checkCreate(task.Status, counts); err != nil {
+			return models.Task{}, false, err
+		}
+		counts[task.Status]++
+		return task, true, nil
+	}
+
+	task.ApplyDefaults()
+	task.CopyManaged(*stored)
+	if err := tc.checkMove(stored.Status, task.Status, counts); err != nil {
+		return models.Task{}, false, err
+	}
+	completeErr, reopenErr, err := data.CheckStatusChange(tc.store, stored.ID)
+	if err != nil {
+		return models.Task{}, false, err
+	}
+	if err := checkCompletion(*stored, task.Status, completeErr); err != nil {
+		return models.Task{}, false, err
+	}
+	if isCompletion(*stored, task.Status) {
+		if data.HasNextOccurrence(task) {
+			if err := tc.checkNextOccurrence(stored.Status, task.Status, counts); err != nil {
+				return models.Task{}, false, err
+			}
+			counts[tc.workflow.InitialStatus()]++
+		}
+	}
+	if !stored.Status.Open() && task.Status.Open() && reopenErr != nil {
+		return models.Task{}, false, reopenErr
+	}
+	counts[stored.Status]--
+	counts[task.Status]++
+	return task, false, nil
+}
+
+// readImportJSON decodes a JSON array of tasks. Elements that do not decode
+// into a task are reported on their own.
+func readImportJSON(r io.Reader) ([]importRecord, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		var tooLarge *http.MaxBytesError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &tooLarge):
+			return nil, err
+		case errors.As(err, &typeErr):
+			return nil, errors.New("body must be a JSON array of tasks")
+		}
+		return nil, fmt.Errorf("body must be a JSON array of tasks: %w", err)
+	}
+	records := make([]importRecord, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &records[i].task); err != nil {
+			records[i].status, records[i].body = http.StatusBadRequest, gin.H{"error": err.Error()}
+		}
+	}
+	return records, nil
+}
+
+// readImportCSV decodes a CSV file whose header names some of csvColumns.
+// Each row is turned into the task's JSON form and decoded like a JSON
+// import, so both formats follow the same rules.
+func readImportCSV(r io.Reader) ([]importRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown CSV column %q (allowed: %s)", name, strings.Join(csvColumns, ", "))
+		}
+		if slices.Contains(header[:i], name) {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		header[i] = name
+	}
+
+	var records []importRecord
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, csvRecord(header, row))
+		if len(records) > maxImportRows {
+			return records, nil
+		}
+	}
+}
+
+// csvRecord decodes one CSV row. Empty cells are left out, and the prefix
+// writeCSV adds to cells that look like formulas is removed.
+func csvRecord(header, row []string) importRecord {
+	if len(row) != len(header) {
+		return importRecord{status: http.StatusBadRequest, body: gin.H{"error": fmt.Sprintf("row has %d fields, the header has %d", len(row), len(header))}}
+	}
+	fields := map[string]any{}
+	for i, name := range header {
+		value := unescapeCSVCell(row[i])
+		if value == "" {
+			continue
+		}
+		switch name {
+		case "labels":
+			fields[name] = strings.Split(value, csvLabelSep)
+		case "recurrence":
+			rule, err := models.ParseRecurrence(value)
+			if err != nil {
+				return importRecord{status: http.StatusUnprocessableEntity, body: gin.H{
+					"error":  "validation failed",
+					"fields": []FieldError{{Field: "recurrence", Message: err.Error()}},
+				}}
+			}
+			fields[name] = rule
+		default:
+			fields[name] = value
+		}
+	}
+
+	var rec importRecord
+	doc, err := json.Marshal(fields)
+	if err == nil {
+		err = json.Unmarshal(doc, &rec.task)
+	}
+	if err != nil {
+		rec.status, rec.body = http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+	return rec
+}
+
+// writeCSV writes tasks with a header row of csvColumns. Cells that a
+// spreadsheet app would run as a formula are escaped with escapeCSVCell.
+func writeCSV(w io.Writer, tasks []models.Task) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		var rule string
+		if t.Recurrence != nil {
+			rule = t.Recurrence.String()
+		}
+		row := []string{
+			t.ID,
+			t.Title,
+			t.Description,
+			t.DueDate.Format(time.RFC3339Nano),
+			string(t.Status),
+			string(t.Priority),
+			t.ProjectID,
+			strings.Join(t.Labels, csvLabelSep),
+			rule,
+		}
+		for i := range row {
+			row[i] = escapeCSVCell(row[i])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// escapeCSVCell prefixes a cell starting with one of csvEscapes with
+// csvEscapePrefix, so spreadsheet apps show it as text instead of running it.
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune(csvEscapes, rune(value[0])) {
+		return csvEscapePrefix + value
+	}
+	return value
+}
+
+// unescapeCSVCell undoes escapeCSVCell. Other cells are returned unchanged.
+func unescapeCSVCell(value string) string {
+	if rest, ok := strings.CutPrefix(value, csvEscapePrefix); ok && rest != "" && strings.ContainsRune(csvEscapes, rune(rest[0])) {
+		return rest
+	}
+	return value
+}
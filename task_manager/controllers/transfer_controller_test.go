@@ -0,0 +1,174 @@
+package controllers_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"task_manager/models"
+)
+
+type importResponse struct {
+	Rows []struct {
+		ID     string `json:"id"`
+		Status int    `json:"status"`
+	} `json:"rows"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+func exportCSV(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url + "/tasks/export?format=csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func importCSV(t *testing.T, url, query, body string) (int, importResponse) {
+	t.Helper()
+	resp, err := http.Post(url+"/tasks/import?"+query, "text/csv", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out importResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode, out
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	srv := newTestServer(t)
+	titles := []string{"=HYPERLINK(\"http://example.com\")", "+1", "-list item", "@SUM(A1)", "'quoted", "plain"}
+	for _, title := range titles {
+		if status := doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody(title), nil); status != http.StatusCreated {
+			t.Fatalf("POST /tasks: got %d, want 201", status)
+		}
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(exportCSV(t, srv.URL))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows[1:] {
+		want := titles[i]
+		if want != "plain" {
+			want = "'" + want
+		}
+		if row[1] != want {
+			t.Errorf("row %d: got title %q, want %q", i+1, row[1], want)
+		}
+	}
+
+	// importing the export under new IDs gives back the original titles
+	export := exportCSV(t, srv.URL)
+	if status, resp := importCSV(t, srv.URL, "mode=new_ids", export); status != http.StatusOK || resp.Succeeded != len(titles) {
+		t.Fatalf("import: got %d with %+v, want 200 with %d created", status, resp, len(titles))
+	}
+	var tasks []models.Task
+	doJSON(t, http.MethodGet, srv.URL+"/tasks", "", &tasks)
+	count := map[string]int{}
+	for _, task := range tasks {
+		count[task.Title]++
+	}
+	for _, title := range titles {
+		if count[title] != 2 {
+			t.Errorf("got %d tasks titled %q, want the original and its import", count[title], title)
+		}
+	}
+}
+
+func TestImportModes(t *testing.T) {
+	srv := newTestServer(t)
+	for _, title := range []string{"one", "two"} {
+		doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody(title), nil)
+	}
+	export := exportCSV(t, srv.URL)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantRows   []int
+	}{
+		{"mode=bogus", http.StatusBadRequest, nil},
+		{"", http.StatusOK, []int{http.StatusConflict, http.StatusConflict}},
+		{"mode=upsert&dry_run=true", http.StatusOK, []int{http.StatusOK, http.StatusOK}},
+		{"mode=upsert", http.StatusOK, []int{http.StatusOK, http.StatusOK}},
+		{"mode=new_ids", http.StatusOK, []int{http.StatusCreated, http.StatusCreated}},
+	}
+	for _, tt := range tests {
+		status, resp := importCSV(t, srv.URL, tt.query, export)
+		if status != tt.wantStatus {
+			t.Errorf("import?%s: got %d, want %d", tt.query, status, tt.wantStatus)
+			continue
+		}
+		if len(resp.Rows) != len(tt.wantRows) {
+			t.Errorf("import?%s: got %d rows, want %d", tt.query, len(resp.Rows), len(tt.wantRows))
+			continue
+		}
+		for i, want := range tt.wantRows {
+			if got := resp.Rows[i].Status; got != want {
+				t.Errorf("import?%s row %d: got %d, want %d", tt.query, i+1, got, want)
+			}
+		}
+	}
+
+	var tasks []models.Task
+	doJSON(t, http.MethodGet, srv.URL+"/tasks", "", &tasks)
+	if len(tasks) != 4 {
+		t.Fatalf("got %d tasks, want 4", len(tasks))
+	}
+	for _, task := range tasks {
+		want := 1
+		if task.ID == "1" || task.ID == "2" {
+			want = 2 // replaced by the upsert
+		}
+		if task.Version != want {
+			t.Errorf("task %s: got version %d, want %d", task.ID, task.Version, want)
+		}
+	}
+}
+
+// TestImportDryRunMatchesImport checks that a dry run reports every row the
+// way the real import then answers it.
+func TestImportDryRunMatchesImport(t *testing.T) {
+	workflow := models.DefaultWorkflow()
+	workflow.WIPLimits = map[models.TaskStatus]int{models.StatusPending: 2}
+	srv := newWorkflowServer(t, workflow)
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("existing"), nil)
+
+	file := "title,due_date,status\n" +
+		"done,2030-01-01T00:00:00Z,Completed\n" +
+		"fits,2030-01-01T00:00:00Z,\n" +
+		"over the limit,2030-01-01T00:00:00Z,Pending\n"
+	want := []int{http.StatusUnprocessableEntity, http.StatusCreated, http.StatusConflict}
+
+	for _, query := range []string{"dry_run=true", ""} {
+		status, resp := importCSV(t, srv.URL, query, file)
+		if status != http.StatusOK || len(resp.Rows) != len(want) {
+			t.Fatalf("import?%s: got %d with %d rows, want 200 with %d", query, status, len(resp.Rows), len(want))
+		}
+		for i, row := range resp.Rows {
+			w := want[i]
+			if w == http.StatusCreated && query != "" {
+				w = http.StatusOK // a dry run creates nothing
+			}
+			if row.Status != w {
+				t.Errorf("import?%s row %d: got %d, want %d", query, i+1, row.Status, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"task_manager/models"
+)
+
+// FieldError describes one invalid field in a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// RegisterValidations adds the task validation rules to Gin's validator and
+// makes it report fields by their JSON names.
+func RegisterValidations() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	if err := v.RegisterValidation("task_status", func(fl validator.FieldLevel) bool {
+		return models.TaskStatus(fl.Field().String()).Valid()
+	}); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("frequency", func(fl validator.FieldLevel) bool {
+		return models.Frequency(fl.Field().String()).Valid()
+	}); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("priority", func(fl validator.FieldLevel) bool {
+		return models.Priority(fl.Field().String()).Valid()
+	}); err != nil {
+		return err
+	}
+	return v.RegisterValidation("weekday", func(fl validator.FieldLevel) bool {
+		_, _, err := models.ParseWeekday(fl.Field().String())
+		return err == nil
+	})
+}
+
+// bindJSON binds the request body into obj. On failure it writes a 400 for
+// malformed JSON, a 413 for a body over a http.MaxBytesReader limit or a 422
+// listing every invalid field, and returns false.
+func bindJSON(ctx *gin.Context, obj any) bool {
+	err := ctx.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case writeValidationError(ctx, err):
+	case errors.As(err, &tooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit)})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	return false
+}
+
+// writeValidationError writes a 422 listing every invalid field if err holds
+// validator errors, and reports whether it did.
+func writeValidationError(ctx *gin.Context, err error) bool {
+	body, ok := validationErrorBody(err)
+	if ok {
+		ctx.JSON(http.StatusUnprocessableEntity, body)
+	}
+	return ok
+}
+
+// validationErrorBody returns the 422 body listing every invalid field if err
+// holds validator errors.
+func validationErrorBody(err error) (gin.H, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, FieldError{Field: fe.Field(), Message: fieldMessage(fe)})
+	}
+	return gin.H{"error": "validation failed", "fields": fields}, true
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at most %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	case "min":
+		if fe.Kind() == reflect.Slice {
+			if fe.Param() == "1" {
+				return "must not be empty"
+			}
+			return fmt.Sprintf("must have at least %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "excluded_with":
+		return "cannot be combined with " + strings.ToLower(fe.Param())
+	case "task_status":
+		statuses := make([]string, len(models.TaskStatuses))
+		for i, s := range models.TaskStatuses {
+			statuses[i] = string(s)
+		}
+		return "must be one of: " + strings.Join(statuses, ", ")
+	case "frequency":
+		freqs := make([]string, len(models.Frequencies))
+		for i, f := range models.Frequencies {
+			freqs[i] = string(f)
+		}
+		return "must be one of: " + strings.Join(freqs, ", ")
+	case "priority":
+		priorities := make([]string, len(models.Priorities))
+		for i, p := range models.Priorities {
+			priorities[i] = string(p)
+		}
+		return "must be one of: " + strings.Join(priorities, ", ")
+	case "weekday":
+		return "must be a weekday code (MO, TU, WE, TH, FR, SA, SU), optionally with an ordinal such as 1MO or -1FR"
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
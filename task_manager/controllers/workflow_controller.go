@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/data"
+	"task_manager/models"
+)
+
+type transitionRequest struct {
+	Status models.TaskStatus `json:"status" binding:"required,task_status"`
+}
+
+// BoardColumn is one status column of the board.
+type BoardColumn struct {
+	Status   models.TaskStatus `json:"status"`
+	WIPLimit int               `json:"wip_limit,omitempty"`
+	Count    int               `json:"count"`
+	Tasks    []models.Task     `json:"tasks"`
+}
+
+// GetWorkflow returns the board columns, allowed transitions and WIP limits.
+func (tc *TaskController) GetWorkflow(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, tc.workflow)
+}
+
+// TransitionTask moves a task to the status in the request body, if the
+// workflow allows it and the target column has room. Completing a recurring
+// task creates its next occurrence. If-Match works as for PUT.
+func (tc *TaskController) TransitionTask(ctx *gin.Context) {
+	var req transitionRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	updated, err := tc.changeTask(tc.storeFor(ctx), ctx.Param("id"), func(task *models.Task) error {
+		if err := checkIfMatch(ctx, *task); err != nil {
+			return err
+		}
+		task.Status = req.Status
+		return nil
+	})
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	setTaskETag(ctx, updated)
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// GetBoard returns the tasks matching the GET /tasks filters grouped into
+// the workflow's columns, in column order. Within a column tasks are ordered
+// most urgent first, then by due date, unless sort is given. Paging
+// parameters are ignored.
+func (tc *TaskController) GetBoard(ctx *gin.Context) {
+	q, err := parseTaskQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q.Offset, q.Limit = 0, 0
+	byPriority := len(q.Sort) == 0
+	if byPriority {
+		q.Sort = []data.SortField{{Field: "due_date"}, {Field: "id"}}
+	}
+	tasks, _, err := tc.store.FindTasks(q)
+	if err != nil {
+		writeStoreError(ctx, err)
+		return
+	}
+	if byPriority {
+		slices.SortStableFunc(tasks, func(a, b models.Task) int {
+			return slices.Index(models.Priorities, b.Priority) - slices.Index(models.Priorities, a.Priority)
+		})
+	}
+
+	columns := make([]BoardColumn, len(tc.workflow.Columns))
+	index := map[models.TaskStatus]int{}
+	for i, status := range tc.workflow.Columns {
+		columns[i] = BoardColumn{Status: status, WIPLimit: tc.workflow.WIPLimits[status], Tasks: []models.Task{}}
+		index[status] = i
+	}
+	for _, t := range tasks {
+		if i, ok := index[t.Status]; ok {
+			columns[i].Tasks = append(columns[i].Tasks, t)
+			columns[i].Count++
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"columns": columns})
+}
+
+// lockColumns counts the live tasks in each status and holds columnMu until
+// unlock is called, so the counts stay accurate for the WIP limit checks of
+// one change. Without WIP limits nothing is counted or locked.
+func (tc *TaskController) lockColumns() (counts map[models.TaskStatus]int, unlock func(), err error) {
+	if len(tc.workflow.WIPLimits) == 0 {
+		return nil, func() {}, nil
+	}
+	tc.columnMu.Lock()
+	stats, err := tc.store.CountTasks(data.TaskQuery{})
+	if err != nil {
+		tc.columnMu.Unlock()
+		return nil, nil, err
+	}
+	counts = map[models.TaskStatus]int{}
+	for _, c := range stats {
+		counts[c.Status] += c.Count
+	}
+	return counts, tc.columnMu.Unlock, nil
+}
+
+// columnCounts returns the number of live tasks in each status, without
+// holding columnMu, for checks that write nothing.
+func (tc *TaskController) columnCounts() (map[models.TaskStatus]int, error) {
+	counts, unlock, err := tc.lockColumns()
+	if err != nil {
+		return nil, err
+	}
+	unlock()
+	if counts == nil {
+		counts = map[models.TaskStatus]int{}
+	}
+	return counts, nil
+}
+
+// checkMove rejects a status change the workflow does not allow or that
+// would overfill the target column.
+func (tc *TaskController) checkMove(from, to models.TaskStatus, counts map[models.TaskStatus]int) error {
+	if from == to {
+		return nil
+	}
+	if !tc.workflow.Allows(from, to) {
+		return &models.TransitionError{From: from, To: to, Allowed: tc.workflow.Transitions[from]}
+	}
+	return tc.checkWIPLimit(to, counts)
+}
+
+// applyDefaults fills in the defaults of a new task, which starts in the
+// workflow's initial status unless it names another.
+func (tc *TaskController) applyDefaults(task *models.Task) {
+	if task.Status == "" {
+		task.Status = tc.workflow.InitialStatus()
+	}
+	task.ApplyDefaults()
+}
+
+// checkCreate rejects a new task that does not start in the initial status
+// or would overfill its column.
+func (tc *TaskController) checkCreate(status models.TaskStatus, counts map[models.TaskStatus]int) error {
+	if initial := tc.workflow.InitialStatus(); status != initial {
+		return &models.InitialStatusError{Status: status, Initial: initial}
+	}
+	return tc.checkWIPLimit(status, counts)
+}
+
+// checkNextOccurrence rejects moving a recurring task from one status to
+// another when its next occurrence would not fit the initial column. counts
+// are taken before the move.
+func (tc *TaskController) checkNextOccurrence(from, to models.TaskStatus, counts map[models.TaskStatus]int) error {
+	initial := tc.workflow.InitialStatus()
+	limit := tc.workflow.WIPLimits[initial]
+	if limit == 0 {
+		return nil
+	}
+	n := counts[initial]
+	if from == initial {
+		n--
+	}
+	if to == initial {
+		n++
+	}
+	if n >= limit {
+		return &models.WIPLimitError{Status: initial, Limit: limit}
+	}
+	return nil
+}
+
+// restoreTask brings back a deleted task if its project still exists, it
+// fits the hierarchy rules of Completed tasks (see data.CheckRestore) and its
+// column has room for it.
+func (tc *TaskController) restoreTask(store data.TaskStore, id string) (models.Task, error) {
+	unlockHierarchy := data.LockHierarchy()
+	defer unlockHierarchy()
+	unlockProjects := data.LockProjectRefs()
+	defer unlockProjects()
+	tasks, err := tc.store.GetAllTasks()
+	if err != nil {
+		return models.Task{}, err
+	}
+	counts, unlock, err := tc.lockColumns()
+	if err != nil {
+		return models.Task{}, err
+	}
+	defer unlock()
+	return store.RestoreTask(id, func(task models.Task) error {
+		if err := tc.checkProject(task.ProjectID); err != nil {
+			return err
+		}
+		if err := data.CheckRestore(tasks, task); err != nil {
+			return err
+		}
+		return tc.checkWIPLimit(task.Status, counts)
+	})
+}
+
+// checkWIPLimit rejects adding a task to a column that is already full.
+func (tc *TaskController) checkWIPLimit(status models.TaskStatus, counts map[models.TaskStatus]int) error {
+	if limit := tc.workflow.WIPLimits[status]; limit > 0 && counts[status] >= limit {
+		return &models.WIPLimitError{Status: status, Limit: limit}
+	}
+	return nil
+}
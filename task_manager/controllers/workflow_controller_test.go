@@ -0,0 +1,66 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"task_manager/models"
+)
+
+func TestCreateStartsInInitialStatus(t *testing.T) {
+	srv := newTestServer(t)
+	body := `{"title":"done already","due_date":"2030-01-01T00:00:00Z","status":"Completed"}`
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks", body, nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("POST /tasks as Completed: got %d, want 422", status)
+	}
+	var parent models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("parent"), &parent)
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+parent.ID+"/subtasks", body, nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("POST subtask as Completed: got %d, want 422", status)
+	}
+
+	workflow := models.DefaultWorkflow()
+	workflow.Initial = models.StatusInProgress
+	srv = newWorkflowServer(t, workflow)
+	var created models.Task
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("started"), &created); status != http.StatusCreated {
+		t.Fatalf("POST /tasks: got %d, want 201", status)
+	}
+	if created.Status != models.StatusInProgress {
+		t.Errorf("got status %s, want %s", created.Status, models.StatusInProgress)
+	}
+}
+
+func TestWIPLimitsOnRestoreAndRecurrence(t *testing.T) {
+	workflow := models.DefaultWorkflow()
+	workflow.WIPLimits = map[models.TaskStatus]int{models.StatusPending: 1}
+	srv := newWorkflowServer(t, workflow)
+
+	var first models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("first"), &first)
+	doJSON(t, http.MethodDelete, srv.URL+"/tasks/"+first.ID, "", nil)
+	var second models.Task
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks", `{"title":"daily","due_date":"2030-01-01T00:00:00Z","recurrence":{"freq":"DAILY"}}`, &second); status != http.StatusCreated {
+		t.Fatalf("POST /tasks: got %d, want 201", status)
+	}
+
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+first.ID+"/restore", "", nil); status != http.StatusConflict {
+		t.Errorf("restore into a full column: got %d, want 409", status)
+	}
+
+	// completing the recurring task frees its Pending slot for the next occurrence
+	doJSON(t, http.MethodPost, srv.URL+"/tasks/"+second.ID+"/transition", `{"status":"In Progress"}`, nil)
+	var other models.Task
+	doJSON(t, http.MethodPost, srv.URL+"/tasks", taskBody("other"), &other)
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+second.ID+"/transition", `{"status":"Completed"}`, nil); status != http.StatusConflict {
+		t.Errorf("completing while the next occurrence has no room: got %d, want 409", status)
+	}
+	doJSON(t, http.MethodDelete, srv.URL+"/tasks/"+other.ID, "", nil)
+	var completed models.Task
+	if status := doJSON(t, http.MethodPost, srv.URL+"/tasks/"+second.ID+"/transition", `{"status":"Completed"}`, &completed); status != http.StatusOK {
+		t.Fatalf("completing with room: got %d, want 200", status)
+	}
+	if completed.NextID == "" {
+		t.Error("no next occurrence was created")
+	}
+}
@@ -0,0 +1,389 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"task_manager/models"
+)
+
+// mongoTimeout bounds every MongoDB call made by MongoTaskStore.
+const mongoTimeout = 5 * time.Second
+
+// maxUpdateAttempts is how many times UpdateTask retries when the task
+// changes between being read and being written back.
+const maxUpdateAttempts = 5
+
+// MongoTaskStore is a TaskStore backed by a MongoDB collection.
+// Generated IDs come from a counter document in a separate collection.
+// Soft-deleted tasks keep their document with deleted_at set.
+type MongoTaskStore struct {
+	tasks    *mongo.Collection
+	counters *mongo.Collection
+}
+
+// NewMongoTaskStore returns a store using the "tasks" and "counters"
+// collections of db, creating the status, due date, project and label indexes
+// if needed.
+func NewMongoTaskStore(db *mongo.Database) (*MongoTaskStore, error) {
+	s := &MongoTaskStore{
+		tasks:    db.Collection("tasks"),
+		counters: db.Collection("counters"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	_, err := s.tasks.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "due_date", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "labels", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *MongoTaskStore) GetAllTasks() ([]models.Task, error) {
+	tasks, _, err := s.FindTasks(TaskQuery{})
+	return tasks, err
+}
+
+func (s *MongoTaskStore) FindTasks(q TaskQuery) ([]models.Task, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	// compare strings case-insensitively when sorting, like the in-memory store
+	collation := &options.Collation{Locale: "en", Strength: 2}
+	filter := mongoFilter(q)
+	total, err := s.tasks.CountDocuments(ctx, filter, options.Count().SetCollation(collation))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSkip(int64(max(q.Offset, 0))).SetCollation(collation)
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
+	}
+	// _id breaks ties, so pages neither overlap nor skip tasks
+	sortDoc := bson.D{}
+	for _, f := range q.Sort {
+		field, dir := f.Field, 1
+		if field == "id" {
+			field = "_id"
+		}
+		if f.Desc {
+			dir = -1
+		}
+		sortDoc = append(sortDoc, bson.E{Key: field, Value: dir})
+	}
+	if !slices.ContainsFunc(q.Sort, func(f SortField) bool { return f.Field == "id" }) {
+		sortDoc = append(sortDoc, bson.E{Key: "_id", Value: 1})
+	}
+	opts.SetSort(sortDoc)
+
+	cursor, err := s.tasks.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, 0, err
+	}
+	return tasks, int(total), nil
+}
+
+func (s *MongoTaskStore) CountTasks(q TaskQuery) ([]TaskCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	cursor, err := s.tasks.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: mongoFilter(q)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"project_id": "$project_id", "status": "$status"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []struct {
+		ID struct {
+			ProjectID string            `bson:"project_id"`
+			Status    models.TaskStatus `bson:"status"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	counts := make([]TaskCount, len(groups))
+	for i, g := range groups {
+		counts[i] = TaskCount{ProjectID: g.ID.ProjectID, Status: g.ID.Status, Count: g.Count}
+	}
+	return counts, nil
+}
+
+func (s *MongoTaskStore) GetTaskByID(id string) (*models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	var task models.Task
+	err := s.tasks.FindOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted}).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (s *MongoTaskStore) AddTask(task models.Task) (models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	task.Version = 1
+	if task.ID != "" {
+		_, err := s.tasks.InsertOne(ctx, task)
+		if mongo.IsDuplicateKeyError(err) {
+			return models.Task{}, ErrDuplicateTaskID
+		}
+		if err != nil {
+			return models.Task{}, err
+		}
+		return task, nil
+	}
+
+	// a client may already have taken the next counter value as an explicit
+	// ID, so keep drawing until an insert succeeds
+	for {
+		id, err := s.nextID(ctx)
+		if err != nil {
+			return models.Task{}, err
+		}
+		task.ID = id
+		_, err = s.tasks.InsertOne(ctx, task)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return models.Task{}, err
+		}
+		return task, nil
+	}
+}
+
+// UpdateTask reads the task, applies fn and writes it back only if the stored
+// document is still exactly what was read, retrying on a concurrent change.
+func (s *MongoTaskStore) UpdateTask(id string, fn func(task *models.Task) error) (models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		original, err := s.tasks.FindOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted}).Raw()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Task{}, ErrTaskNotFound
+		}
+		if err != nil {
+			return models.Task{}, err
+		}
+
+		var task models.Task
+		if err := bson.Unmarshal(original, &task); err != nil {
+			return models.Task{}, err
+		}
+		version := task.Version
+		if err := fn(&task); err != nil {
+			return models.Task{}, err
+		}
+		task.Version = version
+		task.ID = id
+		task.Version++
+		task.DeletedAt = nil
+
+		// the original document as filter turns the replace into a compare-and-swap
+		result, err := s.tasks.ReplaceOne(ctx, original, task)
+		if err != nil {
+			return models.Task{}, err
+		}
+		if result.MatchedCount == 1 {
+			return task, nil
+		}
+	}
+	return models.Task{}, ErrUpdateConflict
+}
+
+// UpdateReminderFlags sets the reminder flags only if the stored document is
+// still exactly what fn saw, retrying on a concurrent change like UpdateTask.
+func (s *MongoTaskStore) UpdateReminderFlags(id string, fn func(task *models.Task) error) (models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		original, err := s.tasks.FindOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted}).Raw()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Task{}, ErrTaskNotFound
+		}
+		if err != nil {
+			return models.Task{}, err
+		}
+		var stored models.Task
+		if err := bson.Unmarshal(original, &stored); err != nil {
+			return models.Task{}, err
+		}
+		task := stored
+		if err := fn(&task); err != nil {
+			return models.Task{}, err
+		}
+		stored.Overdue, stored.ReminderSent = task.Overdue, task.ReminderSent
+
+		result, err := s.tasks.UpdateOne(ctx, original, bson.M{"$set": bson.M{"overdue": stored.Overdue, "reminder_sent": stored.ReminderSent}})
+		if err != nil {
+			return models.Task{}, err
+		}
+		if result.MatchedCount == 1 {
+			return stored, nil
+		}
+	}
+	return models.Task{}, ErrUpdateConflict
+}
+
+// DeleteTask marks the task deleted only if it is still exactly what check
+// saw, retrying on a concurrent change like UpdateTask.
+func (s *MongoTaskStore) DeleteTask(id string, check func(task models.Task) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		original, err := s.tasks.FindOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted}).Raw()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			var task models.Task
+			if err := bson.Unmarshal(original, &task); err != nil {
+				return err
+			}
+			if err := check(task); err != nil {
+				return err
+			}
+		}
+
+		result, err := s.tasks.UpdateOne(ctx, original, bson.M{"$set": bson.M{"deleted_at": time.Now()}, "$inc": bson.M{"version": 1}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 1 {
+			return nil
+		}
+	}
+	return ErrUpdateConflict
+}
+
+func (s *MongoTaskStore) RestoreTask(id string, check func(task models.Task) error) (models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		original, err := s.tasks.FindOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}}).Raw()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Task{}, ErrTaskNotFound
+		}
+		if err != nil {
+			return models.Task{}, err
+		}
+		if check != nil {
+			var task models.Task
+			if err := bson.Unmarshal(original, &task); err != nil {
+				return models.Task{}, err
+			}
+			if err := check(task); err != nil {
+				return models.Task{}, err
+			}
+		}
+
+		var task models.Task
+		err = s.tasks.FindOneAndUpdate(ctx, original,
+			bson.M{"$unset": bson.M{"deleted_at": ""}, "$inc": bson.M{"version": 1}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&task)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return models.Task{}, err
+		}
+		return task, nil
+	}
+	return models.Task{}, ErrUpdateConflict
+}
+
+// nextID atomically increments and returns the task ID counter.
+func (s *MongoTaskStore) nextID(ctx context.Context) (string, error) {
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := s.counters.FindOneAndUpdate(ctx,
+		bson.M{"_id": "tasks"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(counter.Seq), nil
+}
+
+// notDeleted matches documents without deleted_at, i.e. live tasks.
+var notDeleted = bson.M{"$exists": false}
+
+// mongoFilter translates the filters of q into a MongoDB query document.
+func mongoFilter(q TaskQuery) bson.M {
+	filter := bson.M{"deleted_at": notDeleted}
+	if q.Status != "" {
+		filter["status"] = q.Status
+	}
+	if q.ParentID != "" {
+		filter["parent_id"] = q.ParentID
+	}
+	if q.ProjectID != "" {
+		filter["project_id"] = q.ProjectID
+	}
+	if q.Priority != "" {
+		filter["priority"] = q.Priority
+	}
+	if len(q.Labels) > 0 {
+		filter["labels"] = bson.M{"$all": q.Labels}
+	}
+	due := bson.M{}
+	if !q.DueBefore.IsZero() {
+		due["$lt"] = q.DueBefore
+	}
+	if !q.DueAfter.IsZero() {
+		due["$gt"] = q.DueAfter
+	}
+	if len(due) > 0 {
+		filter["due_date"] = due
+	}
+	if q.Search != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(q.Search), "$options": "i"}
+		filter["$or"] = bson.A{
+			bson.M{"title": pattern},
+			bson.M{"description": pattern},
+		}
+	}
+	return filter
+}
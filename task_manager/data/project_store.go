@@ -0,0 +1,230 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"task_manager/models"
+)
+
+// ErrProjectNotFound is returned when no project has the requested ID.
+var ErrProjectNotFound = errors.New("project not found")
+
+// ErrProjectInUse is returned when deleting a project that still has tasks.
+var ErrProjectInUse = errors.New("project still has tasks; move or delete them first")
+
+// ProjectStore is the storage for projects. Implementations must be safe for
+// concurrent use.
+type ProjectStore interface {
+	GetProjects() ([]models.Project, error)
+	GetProjectByID(id string) (*models.Project, error)
+	// AddProject stores a new project under a server-generated ID.
+	AddProject(project models.Project) (models.Project, error)
+	// UpdateProject replaces the project with project.ID.
+	UpdateProject(project models.Project) (models.Project, error)
+	DeleteProject(id string) error
+}
+
+// projectRefsMu keeps projects from being removed while a task is being
+// attached to them: task writes hold it for reading from checking the
+// project until the task is saved, RemoveProject holds it for writing.
+var projectRefsMu sync.RWMutex
+
+// LockProjectRefs blocks RemoveProject until the returned function is
+// called. Hold it from checking that a task's project exists until the task
+// is written.
+func LockProjectRefs() (unlock func()) {
+	projectRefsMu.RLock()
+	return projectRefsMu.RUnlock
+}
+
+// RemoveProject deletes a project, refusing with ErrProjectInUse while any
+// task still belongs to it. Deleted tasks do not count.
+func RemoveProject(projects ProjectStore, tasks TaskStore, id string) error {
+	projectRefsMu.Lock()
+	defer projectRefsMu.Unlock()
+
+	if _, err := projects.GetProjectByID(id); err != nil {
+		return err
+	}
+	_, total, err := tasks.FindTasks(TaskQuery{ProjectID: id, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		return ErrProjectInUse
+	}
+	return projects.DeleteProject(id)
+}
+
+// InMemoryProjectStore is a ProjectStore backed by a slice guarded by a
+// RWMutex. Generated IDs are increasing integers.
+type InMemoryProjectStore struct {
+	mu       sync.RWMutex
+	projects []models.Project
+	lastID   int
+}
+
+func NewInMemoryProjectStore() *InMemoryProjectStore {
+	return &InMemoryProjectStore{}
+}
+
+func (s *InMemoryProjectStore) GetProjects() ([]models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Project{}, s.projects...), nil
+}
+
+func (s *InMemoryProjectStore) GetProjectByID(id string) (*models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.indexOf(id)
+	if i < 0 {
+		return nil, ErrProjectNotFound
+	}
+	project := s.projects[i]
+	return &project, nil
+}
+
+func (s *InMemoryProjectStore) AddProject(project models.Project) (models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	project.ID = strconv.Itoa(s.lastID)
+	s.projects = append(s.projects, project)
+	return project, nil
+}
+
+func (s *InMemoryProjectStore) UpdateProject(project models.Project) (models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(project.ID)
+	if i < 0 {
+		return models.Project{}, ErrProjectNotFound
+	}
+	s.projects[i] = project
+	return project, nil
+}
+
+func (s *InMemoryProjectStore) DeleteProject(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(id)
+	if i < 0 {
+		return ErrProjectNotFound
+	}
+	s.projects = append(s.projects[:i], s.projects[i+1:]...)
+	return nil
+}
+
+// indexOf returns the position of the project with the given ID, or -1.
+// Must be called with s.mu held.
+func (s *InMemoryProjectStore) indexOf(id string) int {
+	for i, p := range s.projects {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// MongoProjectStore is a ProjectStore backed by a MongoDB collection.
+// Generated IDs come from the "projects" counter in the counters collection.
+type MongoProjectStore struct {
+	projects *mongo.Collection
+	counters *mongo.Collection
+}
+
+// NewMongoProjectStore returns a store using the "projects" and "counters"
+// collections of db.
+func NewMongoProjectStore(db *mongo.Database) *MongoProjectStore {
+	return &MongoProjectStore{
+		projects: db.Collection("projects"),
+		counters: db.Collection("counters"),
+	}
+}
+
+func (s *MongoProjectStore) GetProjects() ([]models.Project, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	cursor, err := s.projects.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	projects := []models.Project{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (s *MongoProjectStore) GetProjectByID(id string) (*models.Project, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	var project models.Project
+	err := s.projects.FindOne(ctx, bson.M{"_id": id}).Decode(&project)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *MongoProjectStore) AddProject(project models.Project) (models.Project, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := s.counters.FindOneAndUpdate(ctx,
+		bson.M{"_id": "projects"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return models.Project{}, err
+	}
+	project.ID = strconv.Itoa(counter.Seq)
+	if _, err := s.projects.InsertOne(ctx, project); err != nil {
+		return models.Project{}, err
+	}
+	return project, nil
+}
+
+func (s *MongoProjectStore) UpdateProject(project models.Project) (models.Project, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	result, err := s.projects.ReplaceOne(ctx, bson.M{"_id": project.ID}, project)
+	if err != nil {
+		return models.Project{}, err
+	}
+	if result.MatchedCount == 0 {
+		return models.Project{}, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+func (s *MongoProjectStore) DeleteProject(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	result, err := s.projects.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"task_manager/models"
+)
+
+// EventType names a kind of task change.
+type EventType string
+
+const (
+	EventCreated EventType = "task.created"
+	EventUpdated EventType = "task.updated"
+	EventDeleted EventType = "task.deleted"
+)
+
+// TaskEvent is a change to a task. Seq increases by one per event, and ID
+// prefixes it with the broker's epoch, e.g. "m3x9k2a1-42", so clients can
+// resume a stream after the last ID they saw, even across a restart. Deleted
+// events carry the task as it was when it was deleted, with the version the
+// delete gave it.
+type TaskEvent struct {
+	ID   string      `json:"-"`
+	Seq  uint64      `json:"-"`
+	Type EventType   `json:"type"`
+	Task models.Task `json:"task"`
+	At   time.Time   `json:"at"`
+}
+
+// ErrInvalidEventID is returned by Subscribe for an ID no broker hands out.
+var ErrInvalidEventID = errors.New("not an event ID")
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped.
+const subscriberBuffer = 64
+
+// EventBroker fans task events out to subscribers and keeps the most recent
+// ones so reconnecting subscribers can catch up.
+type EventBroker struct {
+	mu     sync.Mutex
+	subs   map[chan TaskEvent]struct{}
+	recent []TaskEvent // at most keep events, oldest first
+	keep   int
+	epoch  string // tells this broker's event IDs from an earlier process's
+	seq    uint64
+	// versions holds the version of the last event published for each task.
+	versions map[string]int
+	closed   bool
+}
+
+// NewEventBroker returns a broker that replays up to keep recent events to
+// subscribers that resume from an earlier event ID.
+func NewEventBroker(keep int) *EventBroker {
+	return &EventBroker{
+		subs:     make(map[chan TaskEvent]struct{}),
+		keep:     keep,
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		versions: make(map[string]int),
+	}
+}
+
+// Publish sends an event to every subscriber. Stores publish after their
+// write, so two changes to one task can reach Publish in the wrong order; an
+// event older than the task's last published version is dropped, since that
+// event already carries the newer task. Subscribers whose buffer is full are
+// dropped (their channel is closed) rather than blocking publishers; they can
+// resubscribe from the last event they received.
+func (b *EventBroker) Publish(typ EventType, task models.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	if last, ok := b.versions[task.ID]; ok && task.Version < last {
+		return
+	}
+	b.versions[task.ID] = task.Version
+	b.seq++
+	ev := TaskEvent{ID: fmt.Sprintf("%s-%d", b.epoch, b.seq), Seq: b.seq, Type: typ, Task: task, At: time.Now().UTC()}
+	b.recent = append(b.recent, ev)
+	if len(b.recent) > b.keep {
+		b.recent = b.recent[len(b.recent)-b.keep:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel of events published from now on, preceded by
+// the retained events after the one with lastID (pass "" for none). An ID from
+// an earlier process gets every retained event, as all of them came after it.
+// The channel is closed when the subscriber falls behind, cancel is called, or
+// the broker is closed.
+func (b *EventBroker) Subscribe(lastID string) (events <-chan TaskEvent, cancel func(), err error) {
+	var epoch string
+	var after uint64
+	if lastID != "" {
+		var seq string
+		var ok bool
+		epoch, seq, ok = strings.Cut(lastID, "-")
+		if after, err = strconv.ParseUint(seq, 10, 64); !ok || epoch == "" || err != nil {
+			return nil, nil, ErrInvalidEventID
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var backlog []TaskEvent
+	if lastID != "" {
+		if epoch != b.epoch {
+			after = 0
+		}
+		for _, ev := range b.recent {
+			if ev.Seq > after {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+	ch := make(chan TaskEvent, len(backlog)+subscriberBuffer)
+	for _, ev := range backlog {
+		ch <- ev
+	}
+	if b.closed {
+		close(ch)
+		return ch, func() {}, nil
+	}
+	b.subs[ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}, nil
+}
+
+// Close ends every subscription and ignores later events. It is meant for
+// server shutdown, so open streams finish instead of holding it up.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// EventTaskStore is a TaskStore that publishes every successful change to an
+// EventBroker. Restored tasks are published as created.
+type EventTaskStore struct {
+	TaskStore
+	events *EventBroker
+}
+
+func NewEventTaskStore(store TaskStore, events *EventBroker) *EventTaskStore {
+	return &EventTaskStore{TaskStore: store, events: events}
+}
+
+func (s *EventTaskStore) AddTask(task models.Task) (models.Task, error) {
+	created, err := s.TaskStore.AddTask(task)
+	if err == nil {
+		s.events.Publish(EventCreated, created)
+	}
+	return created, err
+}
+
+func (s *EventTaskStore) UpdateTask(id string, fn func(task *models.Task) error) (models.Task, error) {
+	updated, err := s.TaskStore.UpdateTask(id, fn)
+	if err == nil {
+		s.events.Publish(EventUpdated, updated)
+	}
+	return updated, err
+}
+
+func (s *EventTaskStore) UpdateReminderFlags(id string, fn func(task *models.Task) error) (models.Task, error) {
+	updated, err := s.TaskStore.UpdateReminderFlags(id, fn)
+	if err == nil {
+		s.events.Publish(EventUpdated, updated)
+	}
+	return updated, err
+}
+
+func (s *EventTaskStore) DeleteTask(id string, check func(task models.Task) error) error {
+	var deleted models.Task
+	err := s.TaskStore.DeleteTask(id, func(task models.Task) error {
+		deleted = task
+		if check != nil {
+			return check(task)
+		}
+		return nil
+	})
+	if err == nil {
+		deleted.Version++ // as the delete left it
+		s.events.Publish(EventDeleted, deleted)
+	}
+	return err
+}
+
+func (s *EventTaskStore) RestoreTask(id string, check func(task models.Task) error) (models.Task, error) {
+	restored, err := s.TaskStore.RestoreTask(id, check)
+	if err == nil {
+		s.events.Publish(EventCreated, restored)
+	}
+	return restored, err
+}
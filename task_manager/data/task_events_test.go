@@ -0,0 +1,85 @@
+package data
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"task_manager/models"
+)
+
+// drain returns the events waiting on events without blocking.
+func drain(events <-chan TaskEvent) []TaskEvent {
+	var got []TaskEvent
+	for {
+		select {
+		case ev := <-events:
+			got = append(got, ev)
+		default:
+			return got
+		}
+	}
+}
+
+func TestBrokerDropsStaleEvents(t *testing.T) {
+	b := NewEventBroker(16)
+	defer b.Close()
+	events, cancel, err := b.Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	b.Publish(EventUpdated, models.Task{ID: "1", Version: 3})
+	b.Publish(EventUpdated, models.Task{ID: "1", Version: 2}) // written first, published late
+	b.Publish(EventUpdated, models.Task{ID: "1", Version: 3, Overdue: true})
+	b.Publish(EventUpdated, models.Task{ID: "2", Version: 1})
+
+	var versions []int
+	for _, ev := range drain(events) {
+		versions = append(versions, ev.Task.Version)
+	}
+	if want := []int{3, 3, 1}; !slices.Equal(versions, want) {
+		t.Errorf("got versions %v, want %v", versions, want)
+	}
+}
+
+func TestBrokerResume(t *testing.T) {
+	b := NewEventBroker(16)
+	defer b.Close()
+	for v := 1; v <= 3; v++ {
+		b.Publish(EventUpdated, models.Task{ID: "1", Version: v})
+	}
+	first := b.recent[0].ID
+
+	tests := []struct {
+		name    string
+		lastID  string
+		want    int
+		wantErr bool
+	}{
+		{"no ID", "", 0, false},
+		{"this process", first, 2, false},
+		{"earlier process", "old-2", 3, false},
+		{"not an event ID", "2", 0, true},
+		{"bad sequence", b.epoch + "-x", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, cancel, err := b.Subscribe(tt.lastID)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEventID) {
+					t.Errorf("got %v, want ErrInvalidEventID", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cancel()
+			if got := len(drain(events)); got != tt.want {
+				t.Errorf("got %d replayed events, want %d", got, tt.want)
+			}
+		})
+	}
+}
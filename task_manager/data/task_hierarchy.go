@@ -0,0 +1,273 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"task_manager/models"
+)
+
+// ErrSelfReference is returned when a task is made its own parent or blocker.
+var ErrSelfReference = errors.New("a task cannot be its own parent or blocker")
+
+// ErrHierarchyCycle is returned when a parent or blocker link would make a task
+// (indirectly) depend on itself.
+var ErrHierarchyCycle = errors.New("link would create a cycle")
+
+// ErrCompletedDependent is returned when a change would leave a Completed
+// task with an open subtask or blocker.
+var ErrCompletedDependent = errors.New("a Completed task cannot have open subtasks or blockers")
+
+// ErrChecklistItemNotFound is returned when a checklist item ID does not exist
+// on the task.
+var ErrChecklistItemNotFound = errors.New("checklist item not found")
+
+// OpenDependenciesError is returned when a task cannot be completed because
+// some of its blockers or subtasks are still open.
+type OpenDependenciesError struct {
+	Blockers []string `json:"blockers,omitempty"`
+	Subtasks []string `json:"subtasks,omitempty"`
+}
+
+func (e *OpenDependenciesError) Error() string {
+	var parts []string
+	if len(e.Blockers) > 0 {
+		parts = append(parts, "open blockers: "+strings.Join(e.Blockers, ", "))
+	}
+	if len(e.Subtasks) > 0 {
+		parts = append(parts, "open subtasks: "+strings.Join(e.Subtasks, ", "))
+	}
+	return "task cannot be completed; " + strings.Join(parts, "; ")
+}
+
+// hierarchyMu serialises parent and blocker changes with each other, so two
+// concurrent links cannot each pass the cycle check and together form a
+// cycle, and with status changes, so no link can be added between checking a
+// task's dependencies and completing it.
+var hierarchyMu sync.Mutex
+
+// LockHierarchy takes the hierarchy lock and returns the function releasing
+// it. It must be held while calling AddSubtask, SetParent, AddBlocker and
+// RemoveBlocker, and from checking OpenDependencies, CheckReopen,
+// CheckStatusChange or CheckRestore until the change they guard is written.
+func LockHierarchy() (unlock func()) {
+	hierarchyMu.Lock()
+	return hierarchyMu.Unlock
+}
+
+// OpenDependencies returns an *OpenDependenciesError if the task has blockers
+// or subtasks that are neither Completed nor Cancelled, and nil otherwise.
+// Blockers that no longer exist are ignored.
+func OpenDependencies(store TaskStore, id string) error {
+	tasks, err := store.GetAllTasks()
+	if err != nil {
+		return err
+	}
+	task, ok := indexTasks(tasks)[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	return openDependencies(tasks, task)
+}
+
+// openDependencies is OpenDependencies for task among tasks.
+func openDependencies(tasks []models.Task, task models.Task) error {
+	byID := indexTasks(tasks)
+	var open OpenDependenciesError
+	for _, blockerID := range task.BlockedBy {
+		if b, ok := byID[blockerID]; ok && b.Status.Open() {
+			open.Blockers = append(open.Blockers, blockerID)
+		}
+	}
+	for _, t := range tasks {
+		if t.ParentID == task.ID && t.Status.Open() {
+			open.Subtasks = append(open.Subtasks, t.ID)
+		}
+	}
+	if len(open.Blockers) == 0 && len(open.Subtasks) == 0 {
+		return nil
+	}
+	return &open
+}
+
+// CheckReopen returns ErrCompletedDependent if moving task id back to an open
+// status would leave a Completed task with an open subtask or blocker: its
+// parent, or a task it blocks, is Completed.
+func CheckReopen(store TaskStore, id string) error {
+	tasks, err := store.GetAllTasks()
+	if err != nil {
+		return err
+	}
+	task, ok := indexTasks(tasks)[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	return completedDependent(tasks, task)
+}
+
+// CheckStatusChange returns what OpenDependencies and CheckReopen would for
+// task id, reading the tasks once: completeErr guards moving it to Completed
+// and reopenErr moving it back to an open status. err is set if the tasks
+// cannot be read.
+func CheckStatusChange(store TaskStore, id string) (completeErr, reopenErr, err error) {
+	tasks, err := store.GetAllTasks()
+	if err != nil {
+		return nil, nil, err
+	}
+	task, ok := indexTasks(tasks)[id]
+	if !ok {
+		return nil, nil, ErrTaskNotFound
+	}
+	return openDependencies(tasks, task), completedDependent(tasks, task), nil
+}
+
+// completedDependent is CheckReopen for task among tasks.
+func completedDependent(tasks []models.Task, task models.Task) error {
+	if parent, ok := indexTasks(tasks)[task.ParentID]; ok && parent.Status == models.StatusCompleted {
+		return ErrCompletedDependent
+	}
+	for _, t := range tasks {
+		if t.Status == models.StatusCompleted && slices.Contains(t.BlockedBy, task.ID) {
+			return ErrCompletedDependent
+		}
+	}
+	return nil
+}
+
+// CheckRestore returns the error bringing back the deleted task among the
+// live tasks would break a Completed task's rules with: an open task cannot
+// return under a Completed parent or blocking a Completed task, and a
+// Completed task cannot return with open blockers or subtasks. The caller must
+// hold LockHierarchy from reading tasks until the restore is written.
+func CheckRestore(tasks []models.Task, task models.Task) error {
+	if task.Status.Open() {
+		return completedDependent(tasks, task)
+	}
+	if task.Status == models.StatusCompleted {
+		return openDependencies(tasks, task)
+	}
+	return nil
+}
+
+// Subtasks returns the direct children of a task.
+func Subtasks(store TaskStore, id string) ([]models.Task, error) {
+	if _, err := store.GetTaskByID(id); err != nil {
+		return nil, err
+	}
+	tasks, _, err := store.FindTasks(TaskQuery{ParentID: id})
+	return tasks, err
+}
+
+// AddSubtask creates task as a new subtask of parentID. A Completed task only
+// takes subtasks that are finished too. The caller must hold LockHierarchy.
+func AddSubtask(store TaskStore, parentID string, task models.Task) (models.Task, error) {
+	parent, err := store.GetTaskByID(parentID)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if parent.Status == models.StatusCompleted && task.Status.Open() {
+		return models.Task{}, ErrCompletedDependent
+	}
+	task.ParentID = parentID
+	return store.AddTask(task)
+}
+
+// SetParent makes parentID the parent of task id, or detaches it from its
+// parent when parentID is empty. An open task cannot move under a Completed
+// one. The caller must hold LockHierarchy.
+func SetParent(store TaskStore, id, parentID string) (models.Task, error) {
+	if parentID != "" {
+		if parentID == id {
+			return models.Task{}, ErrSelfReference
+		}
+		tasks, err := store.GetAllTasks()
+		if err != nil {
+			return models.Task{}, err
+		}
+		byID := indexTasks(tasks)
+		parent, ok := byID[parentID]
+		if !ok {
+			return models.Task{}, fmt.Errorf("parent %w", ErrTaskNotFound)
+		}
+		if task, ok := byID[id]; ok && parent.Status == models.StatusCompleted && task.Status.Open() {
+			return models.Task{}, ErrCompletedDependent
+		}
+		// walk up from the new parent; meeting id means id would be its own ancestor
+		seen := map[string]bool{}
+		for cur := parentID; cur != "" && !seen[cur]; cur = byID[cur].ParentID {
+			if cur == id {
+				return models.Task{}, ErrHierarchyCycle
+			}
+			seen[cur] = true
+		}
+	}
+
+	return store.UpdateTask(id, func(task *models.Task) error {
+		task.ParentID = parentID
+		return nil
+	})
+}
+
+// AddBlocker records that task id cannot be completed before blockerID. A
+// Completed task cannot get an open blocker. The caller must hold
+// LockHierarchy.
+func AddBlocker(store TaskStore, id, blockerID string) (models.Task, error) {
+	if blockerID == id {
+		return models.Task{}, ErrSelfReference
+	}
+	tasks, err := store.GetAllTasks()
+	if err != nil {
+		return models.Task{}, err
+	}
+	byID := indexTasks(tasks)
+	blocker, ok := byID[blockerID]
+	if !ok {
+		return models.Task{}, fmt.Errorf("blocker %w", ErrTaskNotFound)
+	}
+	if task, ok := byID[id]; ok && task.Status == models.StatusCompleted && blocker.Status.Open() {
+		return models.Task{}, ErrCompletedDependent
+	}
+	// depth-first search along blocked_by from the blocker; reaching id means
+	// the blocker already (indirectly) waits on id
+	stack := []string{blockerID}
+	seen := map[string]bool{}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if cur == id {
+			return models.Task{}, ErrHierarchyCycle
+		}
+		if seen[cur] {
+			continue
+		}
+		seen[cur] = true
+		stack = append(stack, byID[cur].BlockedBy...)
+	}
+
+	return store.UpdateTask(id, func(task *models.Task) error {
+		if !slices.Contains(task.BlockedBy, blockerID) {
+			task.BlockedBy = append(task.BlockedBy, blockerID)
+		}
+		return nil
+	})
+}
+
+// RemoveBlocker drops blockerID from the blockers of task id. The caller must
+// hold LockHierarchy.
+func RemoveBlocker(store TaskStore, id, blockerID string) (models.Task, error) {
+	return store.UpdateTask(id, func(task *models.Task) error {
+		task.BlockedBy = slices.DeleteFunc(slices.Clone(task.BlockedBy), func(b string) bool { return b == blockerID })
+		return nil
+	})
+}
+
+func indexTasks(tasks []models.Task) map[string]models.Task {
+	byID := make(map[string]models.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	return byID
+}
@@ -0,0 +1,241 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"task_manager/models"
+)
+
+// HistoryStore keeps the change history of tasks.
+// Implementations must be safe for concurrent use.
+type HistoryStore interface {
+	AddEntry(entry models.HistoryEntry) error
+	// History returns the entries of a task, oldest first.
+	History(taskID string) ([]models.HistoryEntry, error)
+}
+
+// InMemoryHistoryStore is a HistoryStore that keeps entries in process.
+type InMemoryHistoryStore struct {
+	mu      sync.RWMutex
+	entries map[string][]models.HistoryEntry
+}
+
+func NewInMemoryHistoryStore() *InMemoryHistoryStore {
+	return &InMemoryHistoryStore{entries: make(map[string][]models.HistoryEntry)}
+}
+
+func (s *InMemoryHistoryStore) AddEntry(entry models.HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.TaskID] = append(s.entries[entry.TaskID], entry)
+	return nil
+}
+
+func (s *InMemoryHistoryStore) History(taskID string) ([]models.HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.HistoryEntry{}, s.entries[taskID]...), nil
+}
+
+// MongoHistoryStore is a HistoryStore backed by a MongoDB collection.
+type MongoHistoryStore struct {
+	entries *mongo.Collection
+}
+
+// NewMongoHistoryStore returns a store using the "task_history" collection of
+// db, creating its task_id index if needed.
+func NewMongoHistoryStore(db *mongo.Database) (*MongoHistoryStore, error) {
+	s := &MongoHistoryStore{entries: db.Collection("task_history")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	_, err := s.entries.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "at", Value: 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *MongoHistoryStore) AddEntry(entry models.HistoryEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+	_, err := s.entries.InsertOne(ctx, entry)
+	return err
+}
+
+func (s *MongoHistoryStore) History(taskID string) ([]models.HistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	// _id breaks ties between entries written in the same millisecond
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.entries.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.HistoryEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AuditedTaskStore is a TaskStore that records every successful change in a
+// HistoryStore, attributed to its actor. Use As to get a copy acting for
+// another user. Failing to record an entry is logged; the change itself has
+// already been made and is not undone.
+type AuditedTaskStore struct {
+	TaskStore
+	history HistoryStore
+	actor   string
+}
+
+// SystemActor is the actor of changes the server makes on its own.
+const SystemActor = "system"
+
+// NewAuditedTaskStore wraps store, recording changes in history as SystemActor.
+func NewAuditedTaskStore(store TaskStore, history HistoryStore) *AuditedTaskStore {
+	return &AuditedTaskStore{TaskStore: store, history: history, actor: SystemActor}
+}
+
+// As returns a view of the store that records changes as made by actor.
+func (s *AuditedTaskStore) As(actor string) *AuditedTaskStore {
+	return &AuditedTaskStore{TaskStore: s.TaskStore, history: s.history, actor: actor}
+}
+
+// History returns the change history of a task, oldest first.
+func (s *AuditedTaskStore) History(taskID string) ([]models.HistoryEntry, error) {
+	return s.history.History(taskID)
+}
+
+func (s *AuditedTaskStore) AddTask(task models.Task) (models.Task, error) {
+	created, err := s.TaskStore.AddTask(task)
+	if err != nil {
+		return created, err
+	}
+	s.record(created.ID, models.ActionCreated, diffTasks(nil, &created))
+	return created, nil
+}
+
+func (s *AuditedTaskStore) UpdateTask(id string, fn func(task *models.Task) error) (models.Task, error) {
+	var before models.Task
+	updated, err := s.TaskStore.UpdateTask(id, func(task *models.Task) error {
+		// fn may run more than once on retrying stores; keep the last read
+		before = *task
+		return fn(task)
+	})
+	if err != nil {
+		return updated, err
+	}
+	if changes := diffTasks(&before, &updated); len(changes) > 0 {
+		s.record(id, models.ActionUpdated, changes)
+	}
+	return updated, nil
+}
+
+func (s *AuditedTaskStore) UpdateReminderFlags(id string, fn func(task *models.Task) error) (models.Task, error) {
+	var before models.Task
+	updated, err := s.TaskStore.UpdateReminderFlags(id, func(task *models.Task) error {
+		before = *task
+		return fn(task)
+	})
+	if err != nil {
+		return updated, err
+	}
+	if changes := diffTasks(&before, &updated); len(changes) > 0 {
+		s.record(id, models.ActionUpdated, changes)
+	}
+	return updated, nil
+}
+
+// DeleteTask records the task's last values as the previous values of the
+// deletion.
+func (s *AuditedTaskStore) DeleteTask(id string, check func(task models.Task) error) error {
+	var before models.Task
+	err := s.TaskStore.DeleteTask(id, func(task models.Task) error {
+		before = task
+		if check != nil {
+			return check(task)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.record(id, models.ActionDeleted, diffTasks(&before, nil))
+	return nil
+}
+
+func (s *AuditedTaskStore) RestoreTask(id string, check func(task models.Task) error) (models.Task, error) {
+	restored, err := s.TaskStore.RestoreTask(id, check)
+	if err != nil {
+		return restored, err
+	}
+	s.record(id, models.ActionRestored, nil)
+	return restored, nil
+}
+
+func (s *AuditedTaskStore) record(taskID string, action models.ChangeAction, changes []models.FieldChange) {
+	entry := models.HistoryEntry{
+		TaskID:  taskID,
+		Action:  action,
+		Actor:   s.actor,
+		At:      time.Now().UTC(),
+		Changes: changes,
+	}
+	if err := s.history.AddEntry(entry); err != nil {
+		log.Printf("Failed to record %s of task %s: %v", action, taskID, err)
+	}
+}
+
+// diffTasks compares two versions of a task field by field, using their JSON
+// form so fields are named as clients see them. A nil side counts as a task
+// with every field empty. Fields are returned in alphabetical order.
+func diffTasks(before, after *models.Task) []models.FieldChange {
+	prev, next := taskFields(before), taskFields(after)
+	var names []string
+	for name := range prev {
+		names = append(names, name)
+	}
+	for name := range next {
+		if _, ok := prev[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []models.FieldChange
+	for _, name := range names {
+		// the ID never changes and the version changes every time
+		if name == "id" || name == "version" {
+			continue
+		}
+		if !slices.Equal(prev[name], next[name]) {
+			changes = append(changes, models.FieldChange{Field: name, Old: prev[name], New: next[name]})
+		}
+	}
+	return changes
+}
+
+func taskFields(t *models.Task) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if t == nil {
+		return fields
+	}
+	// a models.Task always marshals to a JSON object
+	b, _ := json.Marshal(t)
+	_ = json.Unmarshal(b, &fields)
+	return fields
+}
@@ -0,0 +1,125 @@
+package data
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"task_manager/models"
+)
+
+// SortFields lists the task fields GET /tasks can sort by.
+var SortFields = []string{"id", "title", "due_date", "status"}
+
+// SortField orders tasks by one field.
+type SortField struct {
+	Field string // one of SortFields
+	Desc  bool
+}
+
+// TaskQuery selects, orders and pages a list of tasks.
+// Zero values mean "no filter", "store order" and "no limit".
+type TaskQuery struct {
+	Status    models.TaskStatus
+	DueBefore time.Time // due strictly before
+	DueAfter  time.Time // due strictly after
+	Search    string    // case-insensitive match on title or description
+	ParentID  string    // only direct subtasks of this task
+	ProjectID string
+	Labels    []string // tasks must have every label (normalised, see models.NormalizeLabels)
+	Priority  models.Priority
+	Sort      []SortField
+	Offset    int
+	Limit     int
+}
+
+// Matches reports whether t passes the query's filters.
+func (q TaskQuery) Matches(t models.Task) bool {
+	if q.Status != "" && t.Status != q.Status {
+		return false
+	}
+	if q.ParentID != "" && t.ParentID != q.ParentID {
+		return false
+	}
+	if q.ProjectID != "" && t.ProjectID != q.ProjectID {
+		return false
+	}
+	if q.Priority != "" && t.Priority != q.Priority {
+		return false
+	}
+	for _, l := range q.Labels {
+		if !slices.Contains(t.Labels, l) {
+			return false
+		}
+	}
+	if !q.DueBefore.IsZero() && !t.DueDate.Before(q.DueBefore) {
+		return false
+	}
+	if !q.DueAfter.IsZero() && !t.DueDate.After(q.DueAfter) {
+		return false
+	}
+	if q.Search != "" {
+		needle := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(t.Title), needle) &&
+			!strings.Contains(strings.ToLower(t.Description), needle) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply filters, sorts and pages tasks in place, returning the page and the
+// number of tasks that matched before paging.
+func (q TaskQuery) Apply(tasks []models.Task) ([]models.Task, int) {
+	matched := tasks[:0]
+	for _, t := range tasks {
+		if q.Matches(t) {
+			matched = append(matched, t)
+		}
+	}
+	if len(q.Sort) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			return q.less(matched[i], matched[j])
+		})
+	}
+
+	total := len(matched)
+	offset := max(q.Offset, 0)
+	if offset >= total {
+		return []models.Task{}, total
+	}
+	matched = matched[offset:]
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total
+}
+
+func (q TaskQuery) less(a, b models.Task) bool {
+	for _, s := range q.Sort {
+		c := compareField(a, b, s.Field)
+		if c == 0 {
+			continue
+		}
+		if s.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+func compareField(a, b models.Task, field string) int {
+	switch field {
+	case "id":
+		return strings.Compare(a.ID, b.ID)
+	case "title":
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	case "due_date":
+		return a.DueDate.Compare(b.DueDate)
+	case "status":
+		return strings.Compare(string(a.Status), string(b.Status))
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"sync"
+	"time"
+
+	"task_manager/models"
+)
+
+// recurrenceMu serialises ScheduleNextOccurrence so a task that is completed,
+// reopened and completed again quickly still gets a single next occurrence.
+var recurrenceMu sync.Mutex
+
+// ScheduleNextOccurrence creates the occurrence that follows the recurring
+// task id, links it through NextID and returns task id as updated. It returns
+// nil without error when HasNextOccurrence is false.
+//
+// The new occurrence copies the title, description, rule, parent, project,
+// labels, priority and checklist (with every item unchecked) and starts in
+// status.
+func ScheduleNextOccurrence(store TaskStore, id string, status models.TaskStatus) (*models.Task, error) {
+	recurrenceMu.Lock()
+	defer recurrenceMu.Unlock()
+
+	current, err := store.GetTaskByID(id)
+	if err != nil {
+		return nil, err
+	}
+	due, n, ok := nextOccurrence(*current)
+	if !ok {
+		return nil, nil
+	}
+
+	next := models.Task{
+		Title:       current.Title,
+		Description: current.Description,
+		DueDate:     due,
+		Status:      status,
+		ParentID:    current.ParentID,
+		ProjectID:   current.ProjectID,
+		Labels:      current.Labels,
+		Priority:    current.Priority,
+		Recurrence:  current.Recurrence,
+		Occurrence:  n,
+	}
+	for _, item := range current.Checklist {
+		item.Done = false
+		next.Checklist = append(next.Checklist, item)
+	}
+	created, err := store.AddTask(next)
+	if err != nil {
+		return nil, err
+	}
+	linked, err := store.UpdateTask(id, func(task *models.Task) error {
+		task.NextID = created.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &linked, nil
+}
+
+// HasNextOccurrence reports whether ScheduleNextOccurrence would create an
+// occurrence after task: it recurs, its series has not ended and its next
+// occurrence does not exist yet.
+func HasNextOccurrence(task models.Task) bool {
+	_, _, ok := nextOccurrence(task)
+	return ok
+}
+
+// nextOccurrence returns the due date and number of the occurrence after
+// task, if HasNextOccurrence.
+func nextOccurrence(task models.Task) (time.Time, int, bool) {
+	if task.Recurrence == nil || task.NextID != "" {
+		return time.Time{}, 0, false
+	}
+	n := max(task.Occurrence, 1)
+	due, ok := task.Recurrence.Next(task.DueDate, n)
+	return due, n + 1, ok
+}
@@ -2,58 +2,246 @@ package data
 
 import (
 	"errors"
+	"strconv"
+	"sync"
 	"time"
 
 	"task_manager/models"
 )
 
-var tasks = []models.Task{
-	{ID: "1", Title: "Task 1", Description: "First task", DueDate: time.Now(), Status: "Pending"},
-	{ID: "2", Title: "Task 2", Description: "Second task", DueDate: time.Now().AddDate(0, 0, 1), Status: "In Progress"},
-	{ID: "3", Title: "Task 3", Description: "Third task", DueDate: time.Now().AddDate(0, 0, 2), Status: "Completed"},
+// ErrTaskNotFound is returned when no task has the requested ID.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrDuplicateTaskID is returned when a new task reuses an existing ID.
+var ErrDuplicateTaskID = errors.New("task with this ID already exists")
+
+// ErrUpdateConflict is returned when a task kept changing underneath an update.
+var ErrUpdateConflict = errors.New("task was modified concurrently, please retry")
+
+// TaskStore is the storage the task controllers depend on.
+// Implementations must be safe for concurrent use.
+type TaskStore interface {
+	GetAllTasks() ([]models.Task, error)
+	// FindTasks returns the page of tasks selected by q and the total number
+	// of tasks matching its filters.
+	FindTasks(q TaskQuery) ([]models.Task, int, error)
+	// CountTasks counts the tasks matching the filters of q by project and
+	// status. Sorting and paging are ignored.
+	CountTasks(q TaskQuery) ([]TaskCount, error)
+	GetTaskByID(id string) (*models.Task, error)
+	// AddTask stores a new task at version 1 and returns it. An empty ID is
+	// replaced by a server-generated one; an ID already in use yields
+	// ErrDuplicateTaskID.
+	AddTask(task models.Task) (models.Task, error)
+	// UpdateTask calls fn with a copy of the stored task and saves the result
+	// with the version incremented, unless fn returns an error. The
+	// read-modify-write is atomic: no other update can land between reading
+	// the task and saving it. The task's ID cannot be changed.
+	UpdateTask(id string, fn func(task *models.Task) error) (models.Task, error)
+	// UpdateReminderFlags is UpdateTask for the reminder scanner: only the
+	// Overdue and ReminderSent flags fn sets are saved, and the version is
+	// left alone so the flags do not invalidate clients' ETags.
+	UpdateReminderFlags(id string, fn func(task *models.Task) error) (models.Task, error)
+	// DeleteTask soft-deletes a task: every other method treats it as missing,
+	// except AddTask, which still refuses its ID, and RestoreTask. Deleting and
+	// restoring both increment the version, so ETags taken before a delete no
+	// longer match after the restore. If check is not nil it is called with
+	// the stored task first, atomically with the delete, and an error from it
+	// cancels the delete.
+	DeleteTask(id string, check func(task models.Task) error) error
+	// RestoreTask brings back a soft-deleted task. It returns ErrTaskNotFound
+	// if no deleted task has the ID. check works as for DeleteTask, with the
+	// deleted task.
+	RestoreTask(id string, check func(task models.Task) error) (models.Task, error)
 }
 
-func GetAllTasks() []models.Task {
-	return tasks
+// TaskCount is the number of tasks in one project (empty for tasks without
+// one) with one status.
+type TaskCount struct {
+	ProjectID string
+	Status    models.TaskStatus
+	Count     int
 }
 
-func GetTaskByID(id string) (*models.Task, error) {
+// InMemoryTaskStore is a TaskStore backed by a slice guarded by a RWMutex.
+// Soft-deleted tasks stay in the slice with DeletedAt set.
+// Generated IDs are increasing integers, starting after the highest numeric
+// ID the store was seeded with.
+type InMemoryTaskStore struct {
+	mu     sync.RWMutex
+	tasks  []models.Task
+	lastID int
+}
+
+// NewInMemoryTaskStore returns a store holding a copy of the given tasks.
+func NewInMemoryTaskStore(tasks ...models.Task) *InMemoryTaskStore {
+	s := &InMemoryTaskStore{tasks: append([]models.Task{}, tasks...)}
 	for _, t := range tasks {
-		if t.ID == id {
-			return &t, nil
+		if n, err := strconv.Atoi(t.ID); err == nil && n > s.lastID {
+			s.lastID = n
 		}
 	}
-	return nil, errors.New("task not found")
+	return s
 }
 
-func AddTask(task models.Task) {
-	tasks = append(tasks, task)
+// SampleTasks returns the tasks the server starts with.
+func SampleTasks() []models.Task {
+	return []models.Task{
+		{ID: "1", Title: "Task 1", Description: "First task", DueDate: time.Now(), Status: models.StatusPending, Priority: models.PriorityMedium, Version: 1},
+		{ID: "2", Title: "Task 2", Description: "Second task", DueDate: time.Now().AddDate(0, 0, 1), Status: models.StatusInProgress, Priority: models.PriorityMedium, Version: 1},
+		{ID: "3", Title: "Task 3", Description: "Third task", DueDate: time.Now().AddDate(0, 0, 2), Status: models.StatusCompleted, Priority: models.PriorityMedium, Version: 1},
+	}
 }
 
-func UpdateTask(id string, updated models.Task) error {
-	for i, t := range tasks {
-		if t.ID == id {
-			if updated.Title != "" {
-				tasks[i].Title = updated.Title
-			}
-			if updated.Description != "" {
-				tasks[i].Description = updated.Description
-			}
-			if updated.Status != "" {
-				tasks[i].Status = updated.Status
-			}
-			return nil
+// GetAllTasks returns a snapshot of all tasks that are not deleted.
+func (s *InMemoryTaskStore) GetAllTasks() ([]models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tasks := make([]models.Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		if t.DeletedAt == nil {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (s *InMemoryTaskStore) FindTasks(q TaskQuery) ([]models.Task, int, error) {
+	tasks, _ := s.GetAllTasks()
+	page, total := q.Apply(tasks)
+	return page, total, nil
+}
+
+func (s *InMemoryTaskStore) CountTasks(q TaskQuery) ([]TaskCount, error) {
+	tasks, _ := s.GetAllTasks()
+	var counts []TaskCount
+	index := map[TaskCount]int{} // key has Count zero
+	for _, t := range tasks {
+		if !q.Matches(t) {
+			continue
+		}
+		key := TaskCount{ProjectID: t.ProjectID, Status: t.Status}
+		i, ok := index[key]
+		if !ok {
+			i = len(counts)
+			index[key] = i
+			counts = append(counts, key)
+		}
+		counts[i].Count++
+	}
+	return counts, nil
+}
+
+func (s *InMemoryTaskStore) GetTaskByID(id string) (*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.indexOf(id)
+	if i < 0 || s.tasks[i].DeletedAt != nil {
+		return nil, ErrTaskNotFound
+	}
+	task := s.tasks[i]
+	return &task, nil
+}
+
+func (s *InMemoryTaskStore) AddTask(task models.Task) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if task.ID == "" {
+		task.ID = s.nextID()
+	} else if s.indexOf(task.ID) >= 0 {
+		return models.Task{}, ErrDuplicateTaskID
+	}
+	task.Version = 1
+	s.tasks = append(s.tasks, task)
+	return task, nil
+}
+
+func (s *InMemoryTaskStore) UpdateTask(id string, fn func(task *models.Task) error) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(id)
+	if i < 0 || s.tasks[i].DeletedAt != nil {
+		return models.Task{}, ErrTaskNotFound
+	}
+	task := s.tasks[i]
+	if err := fn(&task); err != nil {
+		return models.Task{}, err
+	}
+	task.ID = id
+	task.Version = s.tasks[i].Version + 1
+	task.DeletedAt = nil
+	s.tasks[i] = task
+	return task, nil
+}
+
+func (s *InMemoryTaskStore) DeleteTask(id string, check func(task models.Task) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(id)
+	if i < 0 || s.tasks[i].DeletedAt != nil {
+		return ErrTaskNotFound
+	}
+	if check != nil {
+		if err := check(s.tasks[i]); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	s.tasks[i].DeletedAt = &now
+	s.tasks[i].Version++
+	return nil
+}
+
+func (s *InMemoryTaskStore) UpdateReminderFlags(id string, fn func(task *models.Task) error) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(id)
+	if i < 0 || s.tasks[i].DeletedAt != nil {
+		return models.Task{}, ErrTaskNotFound
+	}
+	task := s.tasks[i]
+	if err := fn(&task); err != nil {
+		return models.Task{}, err
+	}
+	s.tasks[i].Overdue, s.tasks[i].ReminderSent = task.Overdue, task.ReminderSent
+	return s.tasks[i], nil
+}
+
+func (s *InMemoryTaskStore) RestoreTask(id string, check func(task models.Task) error) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(id)
+	if i < 0 || s.tasks[i].DeletedAt == nil {
+		return models.Task{}, ErrTaskNotFound
+	}
+	if check != nil {
+		if err := check(s.tasks[i]); err != nil {
+			return models.Task{}, err
+		}
+	}
+	s.tasks[i].DeletedAt = nil
+	s.tasks[i].Version++
+	return s.tasks[i], nil
+}
+
+// nextID returns the next unused numeric ID. Must be called with s.mu held.
+func (s *InMemoryTaskStore) nextID() string {
+	for {
+		s.lastID++
+		id := strconv.Itoa(s.lastID)
+		if s.indexOf(id) < 0 {
+			return id
 		}
 	}
-	return errors.New("task not found")
 }
 
-func DeleteTask(id string) error {
-	for i, t := range tasks {
+// indexOf returns the position of the task with the given ID, or -1.
+// Must be called with s.mu held.
+func (s *InMemoryTaskStore) indexOf(id string) int {
+	for i, t := range s.tasks {
 		if t.ID == id {
-			tasks = append(tasks[:i], tasks[i+1:]...)
-			return nil
+			return i
 		}
 	}
-	return errors.New("task not found")
+	return -1
 }
@@ -0,0 +1,370 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"task_manager/models"
+)
+
+// mongoTestURIEnv names the MongoDB server the contract tests also run
+// against. Without it only the in-memory store is tested.
+const mongoTestURIEnv = "TEST_MONGODB_URI"
+
+// storeFactories returns a constructor of empty stores for every TaskStore
+// implementation that can run here.
+func storeFactories() map[string]func(t *testing.T) TaskStore {
+	factories := map[string]func(t *testing.T) TaskStore{
+		"memory": func(t *testing.T) TaskStore { return NewInMemoryTaskStore() },
+	}
+	if uri := os.Getenv(mongoTestURIEnv); uri != "" {
+		factories["mongo"] = func(t *testing.T) TaskStore {
+			store, _ := newTestMongoStore(t, uri)
+			return store
+		}
+	}
+	return factories
+}
+
+// newTestMongoStore returns a MongoTaskStore on a database of its own, which
+// is dropped when the test ends.
+func newTestMongoStore(t *testing.T, uri string) (*MongoTaskStore, *mongo.Database) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("%s=%s: %v", mongoTestURIEnv, uri, err)
+	}
+	db := client.Database(fmt.Sprintf("task_manager_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	store, err := NewMongoTaskStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, db
+}
+
+// runContract runs test against every available TaskStore.
+func runContract(t *testing.T, test func(t *testing.T, store TaskStore)) {
+	for name, newStore := range storeFactories() {
+		t.Run(name, func(t *testing.T) {
+			test(t, newStore(t))
+		})
+	}
+}
+
+func newTask(title string, status models.TaskStatus) models.Task {
+	return models.Task{
+		Title:    title,
+		DueDate:  time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		Status:   status,
+		Priority: models.PriorityMedium,
+	}
+}
+
+func mustAdd(t *testing.T, store TaskStore, task models.Task) models.Task {
+	t.Helper()
+	added, err := store.AddTask(task)
+	if err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+	return added
+}
+
+func TestStoreAddAndGet(t *testing.T) {
+	runContract(t, func(t *testing.T, store TaskStore) {
+		a := mustAdd(t, store, newTask("a", models.StatusPending))
+		b := mustAdd(t, store, newTask("b", models.StatusPending))
+		if a.ID == "" || a.ID == b.ID {
+			t.Fatalf("got IDs %q and %q, want two different generated IDs", a.ID, b.ID)
+		}
+		if a.Version != 1 {
+			t.Errorf("got version %d, want 1", a.Version)
+		}
+
+		got, err := store.GetTaskByID(a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != "a" || !got.DueDate.Equal(a.DueDate) {
+			t.Errorf("got %+v, want %+v", *got, a)
+		}
+		if _, err := store.GetTaskByID("missing"); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("GetTaskByID(missing): got %v, want ErrTaskNotFound", err)
+		}
+
+		explicit := newTask("c", models.StatusPending)
+		explicit.ID = "custom"
+		mustAdd(t, store, explicit)
+		if _, err := store.AddTask(explicit); !errors.Is(err, ErrDuplicateTaskID) {
+			t.Errorf("AddTask(duplicate): got %v, want ErrDuplicateTaskID", err)
+		}
+	})
+}
+
+func TestStoreUpdate(t *testing.T) {
+	runContract(t, func(t *testing.T, store TaskStore) {
+		task := mustAdd(t, store, newTask("a", models.StatusPending))
+
+		updated, err := store.UpdateTask(task.ID, func(task *models.Task) error {
+			task.Title = "b"
+			task.ID = "ignored"
+			task.Version = 42
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.ID != task.ID || updated.Title != "b" || updated.Version != 2 {
+			t.Errorf("got %+v, want title b at version 2 with the same ID", updated)
+		}
+
+		errStop := errors.New("stop")
+		_, err = store.UpdateTask(task.ID, func(task *models.Task) error {
+			task.Title = "c"
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("got %v, want the callback's error", err)
+		}
+		if got, _ := store.GetTaskByID(task.ID); got.Title != "b" || got.Version != 2 {
+			t.Errorf("a failed update changed the task: %+v", *got)
+		}
+
+		if _, err := store.UpdateTask("missing", func(*models.Task) error { return nil }); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("UpdateTask(missing): got %v, want ErrTaskNotFound", err)
+		}
+	})
+}
+
+func TestStoreUpdateReminderFlags(t *testing.T) {
+	runContract(t, func(t *testing.T, store TaskStore) {
+		task := mustAdd(t, store, newTask("a", models.StatusPending))
+
+		updated, err := store.UpdateReminderFlags(task.ID, func(task *models.Task) error {
+			task.Overdue = true
+			task.Title = "ignored"
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := store.GetTaskByID(task.ID)
+		for _, task := range []models.Task{updated, *got} {
+			if !task.Overdue || task.Title != "a" || task.Version != 1 {
+				t.Errorf("got overdue %v, title %q at version %d; want only the flag set, at version 1", task.Overdue, task.Title, task.Version)
+			}
+		}
+	})
+}
+
+// TestStoreConcurrentUpdates checks that concurrent updates are not lost:
+// every update that succeeds is counted in the version and the counter.
+// The MongoDB store may give up with ErrUpdateConflict under contention.
+func TestStoreConcurrentUpdates(t *testing.T) {
+	runContract(t, func(t *testing.T, store TaskStore) {
+		task := mustAdd(t, store, newTask("a", models.StatusPending))
+
+		const workers = 8
+		const perWorker = 10
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					_, err := store.UpdateTask(task.ID, func(task *models.Task) error {
+						task.Occurrence++
+						return nil
+					})
+					switch {
+					case err == nil:
+						mu.Lock()
+						succeeded++
+						mu.Unlock()
+					case !errors.Is(err, ErrUpdateConflict):
+						t.Error(err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		got, err := store.GetTaskByID(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Occurrence != succeeded || got.Version != succeeded+1 {
+			t.Errorf("%d updates succeeded, but the task has counter %d at version %d", succeeded, got.Occurrence, got.Version)
+		}
+		if succeeded == 0 {
+			t.Error("no update succeeded")
+		}
+	})
+}
+
+func TestStoreDeleteAndRestore(t *testing.T) {
+	runContract(t, func(t *testing.T, store TaskStore) {
+		task := mustAdd(t, store, newTask("a", models.StatusPending))
+
+		errStop := errors.New("stop")
+		if err := store.DeleteTask(task.ID, func(models.Task) error { return errStop }); !errors.Is(err, errStop) {
+			t.Errorf("got %v, want the check's error", err)
+		}
+		if _, err := store.GetTaskByID(task.ID); err != nil {
+			t.Errorf("a refused delete removed the task: %v", err)
+		}
+
+		if err := store.DeleteTask(task.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetTaskByID(task.ID); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("GetTaskByID after delete: got %v, want ErrTaskNotFound", err)
+		}
+		if _, err := store.UpdateTask(task.ID, func(*models.Task) error { return nil }); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("UpdateTask after delete: got %v, want ErrTaskNotFound", err)
+		}
+		if all, _ := store.GetAllTasks(); len(all) != 0 {
+			t.Errorf("GetAllTasks after delete: got %d tasks, want 0", len(all))
+		}
+		if _, err := store.AddTask(task); !errors.Is(err, ErrDuplicateTaskID) {
+			t.Errorf("AddTask with a deleted ID: got %v, want ErrDuplicateTaskID", err)
+		}
+		if err := store.DeleteTask(task.ID, nil); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("DeleteTask twice: got %v, want ErrTaskNotFound", err)
+		}
+
+		refused := errors.New("refused")
+		if _, err := store.RestoreTask(task.ID, func(models.Task) error { return refused }); !errors.Is(err, refused) {
+			t.Errorf("RestoreTask with a failing check: got %v, want the check's error", err)
+		}
+		restored, err := store.RestoreTask(task.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.Title != "a" || restored.DeletedAt != nil || restored.Version != 3 {
+			t.Errorf("got %+v, want the live task back at version 3", restored)
+		}
+		if _, err := store.RestoreTask(task.ID, nil); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("RestoreTask of a live task: got %v, want ErrTaskNotFound", err)
+		}
+	})
+}
+
+func TestStoreFindAndCount(t *testing.T) {
+	runContract(t, func(t *testing.T, store TaskStore) {
+		for i, status := range []models.TaskStatus{models.StatusPending, models.StatusInProgress, models.StatusPending, models.StatusCompleted, models.StatusPending} {
+			task := newTask(fmt.Sprintf("task %d", i), status)
+			task.ID = fmt.Sprintf("t%d", i)
+			task.DueDate = task.DueDate.AddDate(0, 0, -i)
+			if i%2 == 0 {
+				task.ProjectID = "p"
+				task.Labels = []string{"even"}
+			}
+			mustAdd(t, store, task)
+		}
+
+		ids := func(tasks []models.Task) []string {
+			var out []string
+			for _, t := range tasks {
+				out = append(out, t.ID)
+			}
+			return out
+		}
+
+		page, total, err := store.FindTasks(TaskQuery{
+			Status: models.StatusPending,
+			Sort:   []SortField{{Field: "due_date"}},
+			Offset: 1,
+			Limit:  1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 3 || !slices.Equal(ids(page), []string{"t2"}) {
+			t.Errorf("got %v of %d, want [t2] of 3", ids(page), total)
+		}
+
+		page, _, _ = store.FindTasks(TaskQuery{Labels: []string{"even"}, Sort: []SortField{{Field: "id", Desc: true}}})
+		if !slices.Equal(ids(page), []string{"t4", "t2", "t0"}) {
+			t.Errorf("label filter: got %v, want [t4 t2 t0]", ids(page))
+		}
+
+		if page, total, _ := store.FindTasks(TaskQuery{Offset: 10}); len(page) != 0 || total != 5 {
+			t.Errorf("offset past the end: got %d tasks of %d, want 0 of 5", len(page), total)
+		}
+
+		counts, err := store.CountTasks(TaskQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		byKey := map[TaskCount]bool{}
+		for _, c := range counts {
+			byKey[c] = true
+		}
+		for _, want := range []TaskCount{
+			{ProjectID: "p", Status: models.StatusPending, Count: 3},
+			{ProjectID: "", Status: models.StatusInProgress, Count: 1},
+			{ProjectID: "", Status: models.StatusCompleted, Count: 1},
+		} {
+			if !byKey[want] {
+				t.Errorf("CountTasks: missing %+v in %+v", want, counts)
+			}
+		}
+	})
+}
+
+// TestMongoIndexes checks that NewMongoTaskStore creates its indexes.
+func TestMongoIndexes(t *testing.T) {
+	uri := os.Getenv(mongoTestURIEnv)
+	if uri == "" {
+		t.Skipf("set %s to run against MongoDB", mongoTestURIEnv)
+	}
+	store, _ := newTestMongoStore(t, uri)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cursor, err := store.tasks.Indexes().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indexes []struct {
+		Key bson.D `bson:"key"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, index := range indexes {
+		var fields []string
+		for _, e := range index.Key {
+			fields = append(fields, e.Key)
+		}
+		keys = append(keys, fmt.Sprint(fields))
+	}
+	for _, want := range []string{"[status]", "[due_date]", "[project_id status]", "[labels]"} {
+		if !slices.Contains(keys, want) {
+			t.Errorf("missing index on %s; have %v", want, keys)
+		}
+	}
+}
@@ -1,8 +1,232 @@
 package main
 
-import "task_manager/router"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"task_manager/controllers"
+	"task_manager/data"
+	"task_manager/models"
+	"task_manager/reminders"
+	"task_manager/router"
+)
+
+// eventBacklog is how many recent task events are kept for clients that
+// reconnect to GET /tasks/events.
+const eventBacklog = 256
 
 func main() {
-	r := router.InitRoutes()
-	r.Run("localhost:8080")
+	stores, err := openStores()
+	if err != nil {
+		log.Fatalf("Failed to open task store: %v", err)
+	}
+	defer stores.close()
+	events := data.NewEventBroker(eventBacklog)
+	store := data.NewAuditedTaskStore(data.NewEventTaskStore(stores.tasks, events), stores.history)
+
+	scanner, err := newReminderScanner(store.As("reminders"))
+	if err != nil {
+		log.Fatalf("Failed to configure reminders: %v", err)
+	}
+
+	workflow, err := loadWorkflow()
+	if err != nil {
+		log.Fatalf("Failed to load workflow: %v", err)
+	}
+
+	var middleware []gin.HandlerFunc
+	if os.Getenv("TRUST_USER_HEADER") == "true" {
+		middleware = append(middleware, controllers.ActorFromHeader())
+	}
+	r := router.InitRoutes(controllers.NewTaskController(store, stores.projects, events, workflow), middleware...)
+	srv := &http.Server{
+		Addr:    "localhost:8080",
+		Handler: r,
+	}
+	// end open event streams, or Shutdown would wait for them until it times out
+	srv.RegisterOnShutdown(events.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scanner.Run(ctx)
+	}()
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
+	}
+	// the scanner stops with ctx; wait so the store is not closed under it
+	wg.Wait()
+}
+
+// stores holds the storage the server runs on.
+type stores struct {
+	tasks    data.TaskStore
+	history  data.HistoryStore
+	projects data.ProjectStore
+	close    func() // releases the stores' resources
+}
+
+// openStores picks the task store from the environment:
+//
+//	TASK_STORE   "memory" (default) or "mongo"
+//	MONGODB_URI  connection string, default mongodb://localhost:27017
+//	MONGODB_DB   database name, default task_manager_db
+//
+// The task history and projects are kept in the same place as the tasks.
+func openStores() (stores, error) {
+	switch kind := os.Getenv("TASK_STORE"); kind {
+	case "", "memory":
+		return stores{
+			tasks:    data.NewInMemoryTaskStore(data.SampleTasks()...),
+			history:  data.NewInMemoryHistoryStore(),
+			projects: data.NewInMemoryProjectStore(),
+			close:    func() {},
+		}, nil
+	case "mongo":
+		uri := getenv("MONGODB_URI", "mongodb://localhost:27017")
+		dbName := getenv("MONGODB_DB", "task_manager_db")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			return stores{}, err
+		}
+		if err := client.Ping(ctx, nil); err != nil {
+			return stores{}, err
+		}
+		db := client.Database(dbName)
+		tasks, err := data.NewMongoTaskStore(db)
+		if err != nil {
+			return stores{}, err
+		}
+		history, err := data.NewMongoHistoryStore(db)
+		if err != nil {
+			return stores{}, err
+		}
+		log.Printf("Using MongoDB task store (%s)", dbName)
+		return stores{
+			tasks:    tasks,
+			history:  history,
+			projects: data.NewMongoProjectStore(db),
+			close: func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = client.Disconnect(ctx)
+			},
+		}, nil
+	default:
+		return stores{}, fmt.Errorf("unknown TASK_STORE %q (want memory or mongo)", kind)
+	}
+}
+
+// newReminderScanner configures the due date scanner from the environment:
+//
+//	REMINDER_INTERVAL  time between scans, default 1m
+//	REMINDER_LEAD      how long before the due date to remind, default 1h
+//	NOTIFIER           "log" (default), "webhook" or "smtp"
+//	WEBHOOK_URL        target of the webhook notifier
+//	SMTP_ADDR          host:port of the SMTP server, default localhost:1025
+//	SMTP_FROM          sender address, default task-manager@localhost
+//	SMTP_TO            comma-separated recipients
+//	SMTP_USERNAME      optional PLAIN auth credentials, with SMTP_PASSWORD
+func newReminderScanner(store data.TaskStore) (*reminders.Scanner, error) {
+	interval, err := time.ParseDuration(getenv("REMINDER_INTERVAL", "1m"))
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("invalid REMINDER_INTERVAL %q", os.Getenv("REMINDER_INTERVAL"))
+	}
+	lead, err := time.ParseDuration(getenv("REMINDER_LEAD", "1h"))
+	if err != nil || lead < 0 {
+		return nil, fmt.Errorf("invalid REMINDER_LEAD %q", os.Getenv("REMINDER_LEAD"))
+	}
+
+	var notifier reminders.Notifier
+	switch kind := getenv("NOTIFIER", "log"); kind {
+	case "log":
+		notifier = reminders.LogNotifier{}
+	case "webhook":
+		url := os.Getenv("WEBHOOK_URL")
+		if url == "" {
+			return nil, errors.New("NOTIFIER=webhook needs WEBHOOK_URL")
+		}
+		notifier = reminders.WebhookNotifier{URL: url}
+	case "smtp":
+		to := os.Getenv("SMTP_TO")
+		if to == "" {
+			return nil, errors.New("NOTIFIER=smtp needs SMTP_TO")
+		}
+		n := reminders.SMTPNotifier{
+			Addr: getenv("SMTP_ADDR", "localhost:1025"),
+			From: getenv("SMTP_FROM", "task-manager@localhost"),
+			To:   strings.Split(to, ","),
+		}
+		if user := os.Getenv("SMTP_USERNAME"); user != "" {
+			host, _, _ := strings.Cut(n.Addr, ":")
+			n.Auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		notifier = n
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER %q (want log, webhook or smtp)", kind)
+	}
+	return reminders.NewScanner(store, notifier, interval, lead), nil
+}
+
+// loadWorkflow reads the status workflow from the JSON file named by
+// WORKFLOW_FILE, or returns the default workflow when it is not set.
+func loadWorkflow() (models.Workflow, error) {
+	path := os.Getenv("WORKFLOW_FILE")
+	if path == "" {
+		return models.DefaultWorkflow(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return models.Workflow{}, err
+	}
+	defer f.Close()
+	var workflow models.Workflow
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&workflow); err != nil {
+		return models.Workflow{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := workflow.Validate(); err != nil {
+		return models.Workflow{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return workflow, nil
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChangeAction is what happened to a task in a HistoryEntry.
+type ChangeAction string
+
+const (
+	ActionCreated  ChangeAction = "created"
+	ActionUpdated  ChangeAction = "updated"
+	ActionDeleted  ChangeAction = "deleted"
+	ActionRestored ChangeAction = "restored"
+)
+
+// FieldChange is one changed task field, named by its JSON key. Old and New
+// hold the JSON values and are null when the field was empty.
+type FieldChange struct {
+	Field string          `json:"field" bson:"field"`
+	Old   json.RawMessage `json:"old" bson:"old,omitempty"`
+	New   json.RawMessage `json:"new" bson:"new,omitempty"`
+}
+
+// HistoryEntry records a single change to a task.
+type HistoryEntry struct {
+	TaskID  string        `json:"task_id" bson:"task_id"`
+	Action  ChangeAction  `json:"action" bson:"action"`
+	Actor   string        `json:"actor" bson:"actor"`
+	At      time.Time     `json:"at" bson:"at"`
+	Changes []FieldChange `json:"changes,omitempty" bson:"changes,omitempty"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Project groups related tasks. Tasks join a project through their ProjectID.
+type Project struct {
+	ID          string `json:"id" bson:"_id"`
+	Name        string `json:"name" bson:"name" binding:"required,max=100"`
+	Description string `json:"description" bson:"description" binding:"max=1000"`
+}
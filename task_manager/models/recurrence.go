@@ -0,0 +1,295 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a recurring task repeats, as in RFC 5545 FREQ.
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+// Frequencies lists every valid Frequency.
+var Frequencies = []Frequency{FrequencyDaily, FrequencyWeekly, FrequencyMonthly}
+
+// Valid reports whether f is one of Frequencies.
+func (f Frequency) Valid() bool {
+	return slices.Contains(Frequencies, f)
+}
+
+// Weekdays are the RFC 5545 day codes, indexed by time.Weekday.
+var Weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// maxSearch bounds how far ahead Next looks for a matching date, so rules
+// that can never match (e.g. the 5th Monday every 12 months) end the series
+// instead of looping.
+const maxSearch = 1000
+
+// Recurrence is an RRULE-style repeat rule. The task's due date is the
+// current occurrence; Next derives the following ones from it.
+//
+// ByDay entries are weekday codes (MO … SU). For MONTHLY rules they may carry
+// an ordinal, e.g. 1MO for the first Monday or -1FR for the last Friday.
+// Until and Count are mutually exclusive; Count includes the first occurrence.
+type Recurrence struct {
+	Freq     Frequency  `json:"freq" bson:"freq" binding:"required,frequency"`
+	Interval int        `json:"interval,omitempty" bson:"interval,omitempty" binding:"omitempty,min=1"` // defaults to 1
+	ByDay    []string   `json:"by_day,omitempty" bson:"by_day,omitempty" binding:"omitempty,dive,weekday"`
+	Until    *time.Time `json:"until,omitempty" bson:"until,omitempty"`
+	Count    int        `json:"count,omitempty" bson:"count,omitempty" binding:"omitempty,min=1,excluded_with=Until"`
+}
+
+// dayRule is a parsed ByDay entry; ordinal 0 means every such weekday.
+type dayRule struct {
+	ordinal int
+	weekday time.Weekday
+}
+
+// ParseWeekday parses a ByDay entry such as "MO", "2TU" or "-1FR".
+func ParseWeekday(s string) (ordinal int, weekday time.Weekday, err error) {
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("invalid weekday %q", s)
+	}
+	code, prefix := s[len(s)-2:], s[:len(s)-2]
+	i := slices.Index(Weekdays, code)
+	if i < 0 {
+		return 0, 0, fmt.Errorf("invalid weekday %q", s)
+	}
+	if prefix != "" {
+		ordinal, err = strconv.Atoi(prefix)
+		if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+			return 0, 0, fmt.Errorf("invalid weekday ordinal %q", s)
+		}
+	}
+	return ordinal, time.Weekday(i), nil
+}
+
+func (r Recurrence) days() []dayRule {
+	days := make([]dayRule, 0, len(r.ByDay))
+	for _, s := range r.ByDay {
+		if ord, wd, err := ParseWeekday(s); err == nil {
+			days = append(days, dayRule{ordinal: ord, weekday: wd})
+		}
+	}
+	return days
+}
+
+func (r Recurrence) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+// Next returns the occurrence after current, which is occurrence number n
+// (1-based) of the series. It returns false once Count or Until ends the series.
+func (r Recurrence) Next(current time.Time, n int) (time.Time, bool) {
+	if r.Count > 0 && n >= r.Count {
+		return time.Time{}, false
+	}
+	var next time.Time
+	var ok bool
+	switch r.Freq {
+	case FrequencyDaily:
+		next, ok = r.nextDaily(current)
+	case FrequencyWeekly:
+		next, ok = r.nextWeekly(current)
+	case FrequencyMonthly:
+		next, ok = r.nextMonthly(current)
+	}
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// Upcoming returns up to limit occurrences following current.
+func (r Recurrence) Upcoming(current time.Time, n, limit int) []time.Time {
+	var out []time.Time
+	for len(out) < limit {
+		next, ok := r.Next(current, n)
+		if !ok {
+			break
+		}
+		out = append(out, next)
+		current, n = next, n+1
+	}
+	return out
+}
+
+// nextDaily steps by Interval days, skipping days not listed in ByDay.
+func (r Recurrence) nextDaily(t time.Time) (time.Time, bool) {
+	days := r.days()
+	for i := 0; i < maxSearch; i++ {
+		t = t.AddDate(0, 0, r.interval())
+		if len(days) == 0 || matchesWeekday(days, t.Weekday()) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextWeekly returns the next ByDay weekday later in the same Monday-based
+// week, or else the first ByDay weekday Interval weeks on. Without ByDay it
+// steps by Interval weeks.
+func (r Recurrence) nextWeekly(t time.Time) (time.Time, bool) {
+	days := r.days()
+	if len(days) == 0 {
+		return t.AddDate(0, 0, 7*r.interval()), true
+	}
+	offset := mondayOffset(t.Weekday())
+	for d := offset + 1; d < 7; d++ {
+		if matchesWeekday(days, weekdayAt(d)) {
+			return t.AddDate(0, 0, d-offset), true
+		}
+	}
+	monday := t.AddDate(0, 0, 7*r.interval()-offset)
+	for d := 0; d < 7; d++ {
+		if matchesWeekday(days, weekdayAt(d)) {
+			return monday.AddDate(0, 0, d), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextMonthly returns the next matching day after t in t's month, or else the
+// first matching day Interval months on. Without ByDay it keeps t's day of the
+// month, skipping months that are too short for it.
+func (r Recurrence) nextMonthly(t time.Time) (time.Time, bool) {
+	days := r.days()
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, t.Nanosecond(), t.Location())
+	}
+
+	if len(days) > 0 {
+		for _, d := range monthDays(year, month, days) {
+			if d > day {
+				return at(year, month, d), true
+			}
+		}
+	}
+	for i := 1; i < maxSearch; i++ {
+		first := at(year, month+time.Month(i*r.interval()), 1)
+		y, m, _ := first.Date()
+		if len(days) > 0 {
+			if ds := monthDays(y, m, days); len(ds) > 0 {
+				return at(y, m, ds[0]), true
+			}
+		} else if day <= daysIn(y, m) {
+			return at(y, m, day), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// monthDays returns the sorted days of the month matching any of days.
+func monthDays(year int, month time.Month, days []dayRule) []int {
+	n := daysIn(year, month)
+	firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	var out []int
+	for d := 1; d <= n; d++ {
+		wd := time.Weekday((int(firstWeekday) + d - 1) % 7)
+		for _, rule := range days {
+			if rule.weekday != wd {
+				continue
+			}
+			fromStart := (d-1)/7 + 1
+			fromEnd := -((n-d)/7 + 1)
+			if rule.ordinal == 0 || rule.ordinal == fromStart || rule.ordinal == fromEnd {
+				out = append(out, d)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func matchesWeekday(days []dayRule, wd time.Weekday) bool {
+	for _, d := range days {
+		if d.weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// mondayOffset returns how many days wd is after Monday.
+func mondayOffset(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
+
+// weekdayAt is the inverse of mondayOffset.
+func weekdayAt(offset int) time.Weekday {
+	return time.Weekday((offset + 1) % 7)
+}
+
+// String renders the rule in RFC 5545 RRULE syntax, e.g.
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10".
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// ParseRecurrence parses the RRULE syntax produced by String, with or
+// without a leading "RRULE:". UNTIL may also be a plain YYYYMMDD date. Values
+// are not checked beyond their syntax; validate the result like any other
+// Recurrence.
+func ParseRecurrence(s string) (*Recurrence, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	var r Recurrence
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "BYDAY":
+			r.ByDay = strings.Split(strings.ToUpper(value), ",")
+		case "UNTIL":
+			var until time.Time
+			if until, err = time.Parse("20060102T150405Z", value); err != nil {
+				until, err = time.Parse("20060102", value)
+			}
+			r.Until = &until
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", strings.ToUpper(key), value)
+		}
+	}
+	return &r, nil
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the DST cases need America/New_York everywhere
+)
+
+func TestRecurrenceUpcoming(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+	local := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, ny) }
+	until := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name  string
+		rule  Recurrence
+		start time.Time
+		want  []time.Time
+		ends  bool // no occurrences follow want
+	}{
+		{
+			name:  "daily",
+			rule:  Recurrence{Freq: FrequencyDaily, Interval: 2},
+			start: utc(2025, 1, 30),
+			want:  []time.Time{utc(2025, 2, 1), utc(2025, 2, 3), utc(2025, 2, 5)},
+		},
+		{
+			name:  "daily on weekdays",
+			rule:  Recurrence{Freq: FrequencyDaily, ByDay: []string{"MO", "TU", "WE", "TH", "FR"}},
+			start: utc(2025, 1, 10), // a Friday
+			want:  []time.Time{utc(2025, 1, 13), utc(2025, 1, 14), utc(2025, 1, 15)},
+		},
+		{
+			name:  "weekly keeps the weekday",
+			rule:  Recurrence{Freq: FrequencyWeekly},
+			start: utc(2025, 1, 1),
+			want:  []time.Time{utc(2025, 1, 8), utc(2025, 1, 15), utc(2025, 1, 22)},
+		},
+		{
+			name:  "weekly by day",
+			rule:  Recurrence{Freq: FrequencyWeekly, ByDay: []string{"TH", "MO"}},
+			start: utc(2025, 1, 6), // a Monday
+			want:  []time.Time{utc(2025, 1, 9), utc(2025, 1, 13), utc(2025, 1, 16)},
+		},
+		{
+			name:  "every other week by day",
+			rule:  Recurrence{Freq: FrequencyWeekly, Interval: 2, ByDay: []string{"MO", "FR"}},
+			start: utc(2025, 1, 10), // a Friday
+			want:  []time.Time{utc(2025, 1, 20), utc(2025, 1, 24), utc(2025, 2, 3)},
+		},
+		{
+			name:  "weekly from a day not in by_day",
+			rule:  Recurrence{Freq: FrequencyWeekly, ByDay: []string{"MO"}},
+			start: utc(2025, 1, 8), // a Wednesday
+			want:  []time.Time{utc(2025, 1, 13), utc(2025, 1, 20)},
+		},
+		{
+			name:  "monthly first Monday",
+			rule:  Recurrence{Freq: FrequencyMonthly, ByDay: []string{"1MO"}},
+			start: utc(2025, 1, 6),
+			want:  []time.Time{utc(2025, 2, 3), utc(2025, 3, 3), utc(2025, 4, 7)},
+		},
+		{
+			name:  "monthly last Friday",
+			rule:  Recurrence{Freq: FrequencyMonthly, ByDay: []string{"-1FR"}},
+			start: utc(2025, 1, 31),
+			want:  []time.Time{utc(2025, 2, 28), utc(2025, 3, 28), utc(2025, 4, 25)},
+		},
+		{
+			name:  "monthly second and fourth Tuesday",
+			rule:  Recurrence{Freq: FrequencyMonthly, ByDay: []string{"2TU", "4TU"}},
+			start: utc(2025, 1, 14),
+			want:  []time.Time{utc(2025, 1, 28), utc(2025, 2, 11), utc(2025, 2, 25)},
+		},
+		{
+			name:  "monthly second to last Sunday every quarter",
+			rule:  Recurrence{Freq: FrequencyMonthly, Interval: 3, ByDay: []string{"-2SU"}},
+			start: utc(2025, 1, 19),
+			want:  []time.Time{utc(2025, 4, 20), utc(2025, 7, 20), utc(2025, 10, 19)},
+		},
+		{
+			name:  "monthly on the 31st skips short months",
+			rule:  Recurrence{Freq: FrequencyMonthly},
+			start: utc(2025, 1, 31),
+			want:  []time.Time{utc(2025, 3, 31), utc(2025, 5, 31), utc(2025, 7, 31)},
+		},
+		{
+			name:  "monthly on the 29th finds leap Februaries",
+			rule:  Recurrence{Freq: FrequencyMonthly, Interval: 12},
+			start: utc(2024, 2, 29),
+			want:  []time.Time{utc(2028, 2, 29), utc(2032, 2, 29)},
+		},
+		{
+			name:  "count includes the first occurrence",
+			rule:  Recurrence{Freq: FrequencyWeekly, ByDay: []string{"MO", "TH"}, Count: 4},
+			start: utc(2025, 1, 6),
+			want:  []time.Time{utc(2025, 1, 9), utc(2025, 1, 13), utc(2025, 1, 16)},
+			ends:  true,
+		},
+		{
+			name:  "count of one",
+			rule:  Recurrence{Freq: FrequencyDaily, Count: 1},
+			start: utc(2025, 1, 6),
+			want:  nil,
+			ends:  true,
+		},
+		{
+			name:  "until is inclusive",
+			rule:  Recurrence{Freq: FrequencyDaily, Until: until(utc(2025, 1, 3))},
+			start: utc(2025, 1, 1),
+			want:  []time.Time{utc(2025, 1, 2), utc(2025, 1, 3)},
+			ends:  true,
+		},
+		{
+			name:  "until before the next occurrence",
+			rule:  Recurrence{Freq: FrequencyDaily, Until: until(utc(2025, 1, 3).Add(-time.Minute))},
+			start: utc(2025, 1, 1),
+			want:  []time.Time{utc(2025, 1, 2)},
+			ends:  true,
+		},
+		{
+			name:  "daily across the spring DST change",
+			rule:  Recurrence{Freq: FrequencyDaily},
+			start: local(2025, 3, 8),
+			want:  []time.Time{local(2025, 3, 9), local(2025, 3, 10)},
+		},
+		{
+			name:  "weekly across the autumn DST change",
+			rule:  Recurrence{Freq: FrequencyWeekly, ByDay: []string{"MO"}},
+			start: local(2025, 10, 27),
+			want:  []time.Time{local(2025, 11, 3), local(2025, 11, 10)},
+		},
+		{
+			name:  "monthly across the spring DST change",
+			rule:  Recurrence{Freq: FrequencyMonthly, ByDay: []string{"-1SU"}},
+			start: local(2025, 2, 23),
+			want:  []time.Time{local(2025, 3, 30), local(2025, 4, 27)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.Upcoming(tt.start, 1, len(tt.want)+1)
+			if tt.ends && len(got) > len(tt.want) {
+				t.Fatalf("got %v, want the series to end after %v", got, tt.want)
+			}
+			if len(got) < len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if !got[i].Equal(want) || got[i].Hour() != want.Hour() {
+					t.Errorf("occurrence %d: got %v, want %v", i+2, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	tests := []struct {
+		in      string
+		ordinal int
+		weekday time.Weekday
+		wantErr bool
+	}{
+		{in: "MO", weekday: time.Monday},
+		{in: "2TU", ordinal: 2, weekday: time.Tuesday},
+		{in: "-1FR", ordinal: -1, weekday: time.Friday},
+		{in: "+5SU", ordinal: 5, weekday: time.Sunday},
+		{in: "0MO", wantErr: true},
+		{in: "6MO", wantErr: true},
+		{in: "-6MO", wantErr: true},
+		{in: "XX", wantErr: true},
+		{in: "M", wantErr: true},
+	}
+	for _, tt := range tests {
+		ordinal, weekday, err := ParseWeekday(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWeekday(%q): got error %v, want error: %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (ordinal != tt.ordinal || weekday != tt.weekday) {
+			t.Errorf("ParseWeekday(%q): got %d %v, want %d %v", tt.in, ordinal, weekday, tt.ordinal, tt.weekday)
+		}
+	}
+}
+
+func TestRecurrenceStringRoundTrip(t *testing.T) {
+	until := time.Date(2025, 6, 30, 23, 59, 59, 0, time.UTC)
+	rules := []Recurrence{
+		{Freq: FrequencyDaily},
+		{Freq: FrequencyWeekly, Interval: 2, ByDay: []string{"MO", "FR"}, Count: 10},
+		{Freq: FrequencyMonthly, ByDay: []string{"-1FR"}, Until: &until},
+	}
+	for _, rule := range rules {
+		parsed, err := ParseRecurrence("RRULE:" + rule.String())
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q): %v", rule.String(), err)
+			continue
+		}
+		if parsed.String() != rule.String() {
+			t.Errorf("got %q back, want %q", parsed.String(), rule.String())
+		}
+	}
+	if _, err := ParseRecurrence("FREQ=DAILY;BYSETPOS=1"); err == nil {
+		t.Error("ParseRecurrence accepted an unsupported part")
+	}
+}
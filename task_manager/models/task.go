@@ -1,11 +1,129 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
+// TaskStatus is the lifecycle state of a task.
+type TaskStatus string
+
+const (
+	StatusPending    TaskStatus = "Pending"
+	StatusInProgress TaskStatus = "In Progress"
+	StatusCompleted  TaskStatus = "Completed"
+	StatusCancelled  TaskStatus = "Cancelled"
+)
+
+// TaskStatuses lists every valid TaskStatus.
+var TaskStatuses = []TaskStatus{StatusPending, StatusInProgress, StatusCompleted, StatusCancelled}
+
+// Valid reports whether s is one of TaskStatuses.
+func (s TaskStatus) Valid() bool {
+	for _, v := range TaskStatuses {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reports whether a task in this status still needs work.
+func (s TaskStatus) Open() bool {
+	return s != StatusCompleted && s != StatusCancelled
+}
+
+// Priority is how urgent a task is.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// Priorities lists every valid Priority, least urgent first.
+var Priorities = []Priority{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// Valid reports whether p is one of Priorities.
+func (p Priority) Valid() bool {
+	return slices.Contains(Priorities, p)
+}
+
+// Task is a unit of work. ParentID, Checklist and BlockedBy are managed
+// through the hierarchy endpoints, Occurrence and NextID by the server when a
+// recurring task is completed, Overdue and ReminderSent by the reminder
+// scanner, and Version by the store; all of them are ignored by POST, PUT and
+// PATCH.
 type Task struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	DueDate     time.Time `json:"due_date"`
-	Status      string    `json:"status"`
+	ID           string          `json:"id" bson:"_id"`
+	Title        string          `json:"title" bson:"title" binding:"required,max=200"`
+	Description  string          `json:"description" bson:"description"`
+	DueDate      time.Time       `json:"due_date" bson:"due_date" binding:"required"`
+	Status       TaskStatus      `json:"status" bson:"status" binding:"omitempty,task_status"` // defaults to Pending
+	ProjectID    string          `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Labels       []string        `json:"labels,omitempty" bson:"labels,omitempty" binding:"omitempty,max=20,dive,max=50"`
+	Priority     Priority        `json:"priority" bson:"priority" binding:"omitempty,priority"` // defaults to medium
+	ParentID     string          `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Checklist    []ChecklistItem `json:"checklist,omitempty" bson:"checklist,omitempty"`
+	BlockedBy    []string        `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"` // IDs of tasks that must be finished first
+	Recurrence   *Recurrence     `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	Occurrence   int             `json:"occurrence,omitempty" bson:"occurrence,omitempty"`       // 1-based position in its series
+	NextID       string          `json:"next_id,omitempty" bson:"next_id,omitempty"`             // the occurrence generated when this one was completed
+	Overdue      bool            `json:"overdue,omitempty" bson:"overdue,omitempty"`             // passed its due date while still open
+	ReminderSent bool            `json:"reminder_sent,omitempty" bson:"reminder_sent,omitempty"` // a due-soon reminder went out
+	Version      int             `json:"version" bson:"version"`                                 // incremented by the store on every change
+	DeletedAt    *time.Time      `json:"-" bson:"deleted_at,omitempty"`                          // set while the task is soft-deleted; stores hide such tasks
+}
+
+// ChecklistItem is one step inside a task.
+type ChecklistItem struct {
+	ID   string `json:"id" bson:"id"`
+	Text string `json:"text" bson:"text" binding:"required,max=200"`
+	Done bool   `json:"done" bson:"done"`
+}
+
+// ApplyDefaults fills in the default status and priority, and normalises the
+// labels: they are trimmed, lower-cased, sorted and deduplicated, and empty
+// ones are dropped.
+func (t *Task) ApplyDefaults() {
+	if t.Status == "" {
+		t.Status = StatusPending
+	}
+	if t.Priority == "" {
+		t.Priority = PriorityMedium
+	}
+	t.Labels = NormalizeLabels(t.Labels)
+}
+
+// NormalizeLabels returns labels trimmed, lower-cased, sorted and without
+// duplicates or empty entries, or nil if none are left.
+func NormalizeLabels(labels []string) []string {
+	var out []string
+	for _, l := range labels {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			out = append(out, l)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// CopyManaged copies the server-managed fields from src, so that generic
+// updates cannot change them. Overdue and ReminderSent are only kept while the
+// due date is unchanged, so moving a task's due date re-arms its reminders.
+func (t *Task) CopyManaged(src Task) {
+	t.ParentID = src.ParentID
+	t.Checklist = src.Checklist
+	t.BlockedBy = src.BlockedBy
+	t.Occurrence = src.Occurrence
+	t.NextID = src.NextID
+	t.Version = src.Version
+	t.Overdue, t.ReminderSent = false, false
+	if t.DueDate.Equal(src.DueDate) {
+		t.Overdue = src.Overdue
+		t.ReminderSent = src.ReminderSent
+	}
 }
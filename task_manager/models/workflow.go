@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Workflow says how tasks move between statuses. Columns orders the statuses
+// on the board, Initial is the status new tasks start in (the first column if
+// empty), Transitions lists the statuses each status may move to, and
+// WIPLimits caps how many tasks a column may hold (zero or missing means no
+// limit).
+type Workflow struct {
+	Columns     []TaskStatus                `json:"columns"`
+	Initial     TaskStatus                  `json:"initial,omitempty"`
+	Transitions map[TaskStatus][]TaskStatus `json:"transitions"`
+	WIPLimits   map[TaskStatus]int          `json:"wip_limits,omitempty"`
+}
+
+// DefaultWorkflow moves tasks from Pending through In Progress to Completed.
+// Open tasks can be cancelled, finished tasks reopened, and work in progress
+// put back. It has no WIP limits.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Columns: []TaskStatus{StatusPending, StatusInProgress, StatusCompleted, StatusCancelled},
+		Initial: StatusPending,
+		Transitions: map[TaskStatus][]TaskStatus{
+			StatusPending:    {StatusInProgress, StatusCancelled},
+			StatusInProgress: {StatusPending, StatusCompleted, StatusCancelled},
+			StatusCompleted:  {StatusInProgress},
+			StatusCancelled:  {StatusPending},
+		},
+	}
+}
+
+// Validate checks that the workflow only names valid statuses, lists each
+// column once, names no status outside its columns and has no negative
+// limits.
+func (w Workflow) Validate() error {
+	if len(w.Columns) == 0 {
+		return errors.New("workflow needs at least one column")
+	}
+	for i, s := range w.Columns {
+		if !s.Valid() {
+			return fmt.Errorf("unknown status %q in columns", s)
+		}
+		if slices.Contains(w.Columns[:i], s) {
+			return fmt.Errorf("status %q is listed twice in columns", s)
+		}
+	}
+	if w.Initial != "" && !slices.Contains(w.Columns, w.Initial) {
+		return fmt.Errorf("initial status %q is not one of the columns", w.Initial)
+	}
+	for from, tos := range w.Transitions {
+		if !slices.Contains(w.Columns, from) {
+			return fmt.Errorf("status %q in transitions is not one of the columns", from)
+		}
+		for _, to := range tos {
+			if !slices.Contains(w.Columns, to) {
+				return fmt.Errorf("status %q in transitions from %q is not one of the columns", to, from)
+			}
+		}
+	}
+	for s, limit := range w.WIPLimits {
+		if !slices.Contains(w.Columns, s) {
+			return fmt.Errorf("status %q in wip_limits is not one of the columns", s)
+		}
+		if limit < 0 {
+			return fmt.Errorf("wip limit for %q cannot be negative", s)
+		}
+	}
+	return nil
+}
+
+// InitialStatus returns the status new tasks start in.
+func (w Workflow) InitialStatus() TaskStatus {
+	if w.Initial == "" && len(w.Columns) > 0 {
+		return w.Columns[0]
+	}
+	return w.Initial
+}
+
+// Allows reports whether a task may move from one status to another.
+// Staying in the same status is always allowed.
+func (w Workflow) Allows(from, to TaskStatus) bool {
+	return from == to || slices.Contains(w.Transitions[from], to)
+}
+
+// TransitionError is returned when the workflow does not allow a status
+// change.
+type TransitionError struct {
+	From, To TaskStatus
+	Allowed  []TaskStatus // where From may move to instead
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move a task from %s to %s", e.From, e.To)
+}
+
+// InitialStatusError is returned when a new task does not start in the
+// workflow's initial status.
+type InitialStatusError struct {
+	Status, Initial TaskStatus
+}
+
+func (e *InitialStatusError) Error() string {
+	return fmt.Sprintf("new tasks start as %s, not %s", e.Initial, e.Status)
+}
+
+// WIPLimitError is returned when a column is already at its WIP limit.
+type WIPLimitError struct {
+	Status TaskStatus
+	Limit  int
+}
+
+func (e *WIPLimitError) Error() string {
+	return fmt.Sprintf("%s already holds its limit of %d tasks", e.Status, e.Limit)
+}
@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+func TestWorkflowValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(w *Workflow)
+		wantErr bool
+	}{
+		{"default", func(w *Workflow) {}, false},
+		{"no initial", func(w *Workflow) { w.Initial = "" }, false},
+		{"no columns", func(w *Workflow) { w.Columns = nil }, true},
+		{"duplicate column", func(w *Workflow) { w.Columns = append(w.Columns, StatusPending) }, true},
+		{"initial outside columns", func(w *Workflow) {
+			w.Columns = w.Columns[:3]
+			w.Transitions = nil
+			w.Initial = StatusCancelled
+		}, true},
+		{"transition from outside columns", func(w *Workflow) {
+			w.Columns = w.Columns[:3]
+			w.Transitions = map[TaskStatus][]TaskStatus{StatusCancelled: {StatusPending}}
+		}, true},
+		{"transition to outside columns", func(w *Workflow) {
+			w.Columns = w.Columns[:3]
+			w.Transitions = map[TaskStatus][]TaskStatus{StatusPending: {StatusCancelled}}
+		}, true},
+		{"wip limit outside columns", func(w *Workflow) {
+			w.Columns = w.Columns[:3]
+			w.Transitions = nil
+			w.WIPLimits = map[TaskStatus]int{StatusCancelled: 1}
+		}, true},
+		{"negative wip limit", func(w *Workflow) { w.WIPLimits = map[TaskStatus]int{StatusPending: -1} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := DefaultWorkflow()
+			tt.edit(&w)
+			if err := w.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"task_manager/models"
+)
+
+// Kind tells why a reminder was sent.
+type Kind string
+
+const (
+	KindDueSoon Kind = "due_soon"
+	KindOverdue Kind = "overdue"
+)
+
+// Reminder is a single notification about a task.
+type Reminder struct {
+	Kind Kind        `json:"kind"`
+	Task models.Task `json:"task"`
+	At   time.Time   `json:"at"` // when the scanner noticed
+}
+
+func (r Reminder) String() string {
+	if r.Kind == KindOverdue {
+		return fmt.Sprintf("Task %s %q is overdue (was due %s)", r.Task.ID, r.Task.Title, r.Task.DueDate.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("Task %s %q is due at %s", r.Task.ID, r.Task.Title, r.Task.DueDate.Format(time.RFC3339))
+}
+
+// Notifier delivers reminders. Implementations must be safe for concurrent use.
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// LogNotifier writes reminders to the standard logger.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(_ context.Context, r Reminder) error {
+	log.Printf("Reminder: %s", r)
+	return nil
+}
+
+// WebhookNotifier POSTs each reminder as JSON to URL and expects a 2xx reply.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client // defaults to a client with a 10 second timeout
+}
+
+var defaultWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+func (n WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := n.Client
+	if client == nil {
+		client = defaultWebhookClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %s", n.URL, resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier mails each reminder through the server at Addr (host:port).
+// Auth may be nil for local test servers that accept unauthenticated mail.
+type SMTPNotifier struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+var headerSafe = strings.NewReplacer("\r", " ", "\n", " ")
+
+// smtpTimeout bounds one SMTP session, so a server that stops answering
+// cannot hold up the scanner.
+const smtpTimeout = 30 * time.Second
+
+// Notify sends the mail like smtp.SendMail, but gives up when ctx is done or
+// after smtpTimeout, whichever comes first.
+func (n SMTPNotifier) Notify(ctx context.Context, r Reminder) error {
+	for _, addr := range append([]string{n.From}, n.To...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("smtp: address %q contains a line break", addr)
+		}
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	// titles are user input; keep them from adding header lines
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerSafe.Replace(r.String()))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n%s\r\n", r, r.Task.Description)
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// unblock any pending read or write as soon as ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(n.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(n.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, msg.String()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
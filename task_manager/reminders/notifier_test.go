@@ -0,0 +1,170 @@
+package reminders
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"task_manager/models"
+)
+
+var testReminder = Reminder{
+	Kind: KindOverdue,
+	Task: models.Task{ID: "7", Title: "Pay rent\r\nBcc: everyone@example.com", Description: "Before noon", DueDate: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+	At:   time.Date(2030, 1, 1, 1, 0, 0, 0, time.UTC),
+}
+
+// smtpSession is what fakeSMTPServer received in one session.
+type smtpSession struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer serves one SMTP session on a local port, without STARTTLS or
+// AUTH, and sends what it received on the returned channel.
+func fakeSMTPServer(t *testing.T) (addr string, sessions <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var s smtpSession
+		reply("220 localhost ESMTP test")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				s.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				s.to = append(s.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				s.data = data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				ch <- s
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, sessions := fakeSMTPServer(t)
+	n := SMTPNotifier{Addr: addr, From: "tasks@example.com", To: []string{"a@example.com", "b@example.com"}}
+	if err := n.Notify(context.Background(), testReminder); err != nil {
+		t.Fatal(err)
+	}
+	s := <-sessions
+	if s.from != n.From || strings.Join(s.to, ",") != "a@example.com,b@example.com" {
+		t.Errorf("got envelope from %q to %v, want %q to %v", s.from, s.to, n.From, n.To)
+	}
+	headers, body, _ := strings.Cut(s.data, "\r\n\r\n")
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("task title added a header:\n%s", headers)
+	}
+	if !strings.Contains(headers, "Subject: Task 7 ") || !strings.Contains(body, "Before noon") {
+		t.Errorf("got message\n%s\nwant the reminder as subject and the description in the body", s.data)
+	}
+}
+
+func TestSMTPNotifierRejectsLineBreaksInAddresses(t *testing.T) {
+	n := SMTPNotifier{Addr: "127.0.0.1:1", From: "tasks@example.com", To: []string{"a@example.com\r\nRCPT TO:<b@example.com>"}}
+	if err := n.Notify(context.Background(), testReminder); err == nil {
+		t.Error("got no error for an address with a line break")
+	}
+}
+
+func TestSMTPNotifierHonoursContext(t *testing.T) {
+	// a server that accepts connections but never greets
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = SMTPNotifier{Addr: ln.Addr().String(), From: "tasks@example.com", To: []string{"a@example.com"}}.Notify(ctx, testReminder)
+	if err == nil {
+		t.Fatal("got no error from a server that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Notify returned after %v, want soon after the context ended", elapsed)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Reminder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	if err := (WebhookNotifier{URL: srv.URL}).Notify(context.Background(), testReminder); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != KindOverdue || got.Task.ID != "7" {
+		t.Errorf("got %+v, want the reminder", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := (WebhookNotifier{URL: failing.URL}).Notify(context.Background(), testReminder); err == nil {
+		t.Error("got no error for a 502 reply")
+	}
+}
@@ -0,0 +1,109 @@
+// Package reminders watches task due dates in the background, marks tasks
+// that are past due and sends reminders through a pluggable Notifier.
+package reminders
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"task_manager/data"
+	"task_manager/models"
+)
+
+// Scanner periodically looks for open tasks that are due within Lead or past
+// due. Each task gets at most one due-soon and one overdue reminder per due
+// date: the flag is saved on the task before the notifier is called, so a
+// failed delivery is logged rather than retried.
+type Scanner struct {
+	Store    data.TaskStore
+	Notifier Notifier
+	Interval time.Duration    // time between scans
+	Lead     time.Duration    // how long before the due date to remind
+	Now      func() time.Time // the clock; time.Now if nil
+}
+
+// NewScanner returns a Scanner with the given settings.
+func NewScanner(store data.TaskStore, notifier Notifier, interval, lead time.Duration) *Scanner {
+	return &Scanner{Store: store, Notifier: notifier, Interval: interval, Lead: lead}
+}
+
+// Run scans once immediately and then every Interval until ctx is cancelled.
+// Scan errors are logged and do not stop the loop.
+func (s *Scanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Scan(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Reminder scan failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan checks every open task due before now+Lead once and sends the
+// reminders that are due.
+func (s *Scanner) Scan(ctx context.Context) error {
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	tasks, _, err := s.Store.FindTasks(data.TaskQuery{DueBefore: now.Add(s.Lead)})
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !t.Status.Open() {
+			continue
+		}
+		var kind Kind
+		switch {
+		case !t.DueDate.After(now) && !t.Overdue:
+			kind = KindOverdue
+		case t.DueDate.After(now) && !t.ReminderSent:
+			kind = KindDueSoon
+		default:
+			continue
+		}
+
+		updated, err := s.Store.UpdateReminderFlags(t.ID, func(task *models.Task) error {
+			// the task may have been completed, moved or flagged since FindTasks
+			if !task.Status.Open() || !task.DueDate.Equal(t.DueDate) {
+				return errSkip
+			}
+			if kind == KindOverdue {
+				if task.Overdue {
+					return errSkip
+				}
+				task.Overdue = true
+			} else {
+				if task.ReminderSent {
+					return errSkip
+				}
+				task.ReminderSent = true
+			}
+			return nil
+		})
+		if errors.Is(err, errSkip) || errors.Is(err, data.ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.Notifier.Notify(ctx, Reminder{Kind: kind, Task: updated, At: now}); err != nil {
+			log.Printf("Reminder for task %s not delivered: %v", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// errSkip aborts an UpdateReminderFlags whose task no longer needs a reminder.
+var errSkip = errors.New("reminder no longer needed")
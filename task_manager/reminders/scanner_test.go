@@ -0,0 +1,106 @@
+package reminders
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"task_manager/data"
+	"task_manager/models"
+)
+
+// recordingNotifier keeps the reminders it is given.
+type recordingNotifier struct {
+	mu        sync.Mutex
+	reminders []Reminder
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, r Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reminders = append(n.reminders, r)
+	return nil
+}
+
+// take returns the reminders received since the last call as task ID to kind.
+func (n *recordingNotifier) take() map[string]Kind {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	got := map[string]Kind{}
+	for _, r := range n.reminders {
+		got[r.Task.ID] = r.Kind
+	}
+	n.reminders = nil
+	return got
+}
+
+func TestScan(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	task := func(id string, due time.Duration, status models.TaskStatus) models.Task {
+		return models.Task{ID: id, Title: id, DueDate: now.Add(due), Status: status}
+	}
+	store := data.NewInMemoryTaskStore(
+		task("soon", 30*time.Minute, models.StatusPending),
+		task("later", 3*time.Hour, models.StatusPending),
+		task("late", -time.Hour, models.StatusInProgress),
+		task("done", -time.Hour, models.StatusCompleted),
+		task("dropped", 30*time.Minute, models.StatusCancelled),
+	)
+	seeded, err := store.GetTaskByID("soon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier := &recordingNotifier{}
+	clock := now
+	s := NewScanner(store, notifier, time.Minute, time.Hour)
+	s.Now = func() time.Time { return clock }
+
+	steps := []struct {
+		name string
+		at   time.Time
+		want map[string]Kind
+	}{
+		{"first scan", now, map[string]Kind{"soon": KindDueSoon, "late": KindOverdue}},
+		{"same reminders are not sent twice", now.Add(time.Minute), map[string]Kind{}},
+		{"due dates pass", now.Add(2*time.Hour + 30*time.Minute), map[string]Kind{"soon": KindOverdue, "later": KindDueSoon}},
+	}
+	for _, step := range steps {
+		clock = step.at
+		if err := s.Scan(context.Background()); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got := notifier.take()
+		if len(got) != len(step.want) {
+			t.Errorf("%s: got reminders %v, want %v", step.name, got, step.want)
+			continue
+		}
+		for id, kind := range step.want {
+			if got[id] != kind {
+				t.Errorf("%s: task %s got %q, want %q", step.name, id, got[id], kind)
+			}
+		}
+	}
+
+	soon, err := store.GetTaskByID("soon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !soon.Overdue || !soon.ReminderSent || soon.Version != seeded.Version {
+		t.Errorf("got overdue %v, reminder_sent %v, version %d; want both flags set and version %d", soon.Overdue, soon.ReminderSent, soon.Version, seeded.Version)
+	}
+}
+
+func TestScanStopsWhenCancelled(t *testing.T) {
+	now := time.Now()
+	store := data.NewInMemoryTaskStore(models.Task{ID: "1", DueDate: now.Add(-time.Hour), Status: models.StatusPending})
+	notifier := &recordingNotifier{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewScanner(store, notifier, time.Minute, time.Hour).Scan(ctx); err == nil {
+		t.Error("got no error from a cancelled scan")
+	}
+	if got := notifier.take(); len(got) != 0 {
+		t.Errorf("got reminders %v from a cancelled scan", got)
+	}
+}
@@ -5,14 +5,50 @@ import (
 	"task_manager/controllers"
 )
 
-func InitRoutes() *gin.Engine {
+// InitRoutes registers the API routes of tc. The middleware runs before every
+// route; authentication middleware goes here and names the user by setting
+// controllers.ActorKey.
+func InitRoutes(tc *controllers.TaskController, middleware ...gin.HandlerFunc) *gin.Engine {
+	if err := controllers.RegisterValidations(); err != nil {
+		panic(err)
+	}
 	r := gin.Default()
+	r.Use(middleware...)
 
-	r.GET("/tasks", controllers.GetTasks)
-	r.GET("/tasks/:id", controllers.GetTaskByID)
-	r.POST("/tasks", controllers.AddTask)
-	r.PUT("/tasks/:id", controllers.UpdateTask)
-	r.DELETE("/tasks/:id", controllers.DeleteTask)
+	r.GET("/tasks", tc.GetTasks)
+	r.GET("/tasks/events", tc.StreamTasks)
+	r.GET("/tasks/stats", tc.GetTaskStats)
+	r.GET("/tasks/:id", tc.GetTaskByID)
+	r.POST("/tasks", tc.AddTask)
+	r.POST("/tasks/bulk", tc.BulkTasks)
+	r.GET("/tasks/export", tc.ExportTasks)
+	r.POST("/tasks/import", tc.ImportTasks)
+	r.PUT("/tasks/:id", tc.UpdateTask)
+	r.PATCH("/tasks/:id", tc.PatchTask)
+	r.DELETE("/tasks/:id", tc.DeleteTask)
+	r.POST("/tasks/:id/transition", tc.TransitionTask)
+
+	r.GET("/tasks/:id/subtasks", tc.GetSubtasks)
+	r.POST("/tasks/:id/subtasks", tc.AddSubtask)
+	r.PUT("/tasks/:id/parent", tc.SetParent)
+	r.DELETE("/tasks/:id/parent", tc.RemoveParent)
+	r.POST("/tasks/:id/blockers", tc.AddBlocker)
+	r.DELETE("/tasks/:id/blockers/:blockerId", tc.RemoveBlocker)
+	r.POST("/tasks/:id/checklist", tc.AddChecklistItem)
+	r.PATCH("/tasks/:id/checklist/:itemId", tc.PatchChecklistItem)
+	r.DELETE("/tasks/:id/checklist/:itemId", tc.DeleteChecklistItem)
+	r.GET("/tasks/:id/occurrences", tc.GetOccurrences)
+	r.GET("/tasks/:id/history", tc.GetTaskHistory)
+	r.POST("/tasks/:id/restore", tc.RestoreTask)
+
+	r.GET("/board", tc.GetBoard)
+	r.GET("/workflow", tc.GetWorkflow)
+
+	r.GET("/projects", tc.GetProjects)
+	r.GET("/projects/:id", tc.GetProjectByID)
+	r.POST("/projects", tc.AddProject)
+	r.PUT("/projects/:id", tc.UpdateProject)
+	r.DELETE("/projects/:id", tc.DeleteProject)
 
 	return r
 }
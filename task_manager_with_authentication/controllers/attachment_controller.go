@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/data"
+	"task_manager/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// multipartOverhead is the room left above data.MaxAttachmentSize for the
+// rest of an upload request's multipart body
+const multipartOverhead = 1 << 20
+
+// ----------------------------
+// LIST ATTACHMENTS
+// GET /tasks/:id/attachments
+// ----------------------------
+func GetTaskAttachments(c *gin.Context) {
+	task, ok := loadTask(c, accessRead)
+	if !ok {
+		return
+	}
+
+	attachments, err := data.GetTaskAttachments(task.ID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": attachments})
+}
+
+// ----------------------------
+// UPLOAD ATTACHMENT (OWNER, ASSIGNEE, WRITE COLLABORATOR OR ADMIN)
+// POST /tasks/:id/attachments
+// Multipart form with the file in the "file" field
+// ----------------------------
+func UploadAttachment(c *gin.Context) {
+	task, ok := loadTask(c, accessWrite)
+	if !ok {
+		return
+	}
+	userID, _ := currentUserID(c)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, data.MaxAttachmentSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondTaskError(c, data.ErrAttachmentTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a file is required in the \"file\" form field"})
+		return
+	}
+	if header.Size > data.MaxAttachmentSize {
+		respondTaskError(c, data.ErrAttachmentTooLarge)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := data.SaveAttachment(task.ID, header.Filename, file, userID, c.GetString("username"))
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "attachment uploaded successfully",
+		"data":    attachment,
+	})
+}
+
+// ----------------------------
+// DOWNLOAD ATTACHMENT
+// GET /tasks/:id/attachments/:attachmentId
+// ----------------------------
+func DownloadAttachment(c *gin.Context) {
+	attachment, ok := loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	contents, err := data.OpenAttachment(attachment)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+	defer contents.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, contents, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// ----------------------------
+// DELETE ATTACHMENT (UPLOADER OR ADMIN)
+// DELETE /tasks/:id/attachments/:attachmentId
+// ----------------------------
+func DeleteAttachment(c *gin.Context) {
+	attachment, ok := loadAttachment(c)
+	if !ok {
+		return
+	}
+	userID, _ := currentUserID(c)
+
+	if attachment.UploaderID != userID && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the uploader or an admin can delete this attachment"})
+		return
+	}
+
+	if err := data.DeleteAttachment(attachment.TaskID, attachment.ID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "attachment deleted successfully"})
+}
+
+// loadAttachment fetches the attachment named by :attachmentId on a task
+// the caller can read
+func loadAttachment(c *gin.Context) (models.Attachment, bool) {
+	task, ok := loadTask(c, accessRead)
+	if !ok {
+		return models.Attachment{}, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment ID"})
+		return models.Attachment{}, false
+	}
+
+	attachment, err := data.GetAttachment(task.ID, id)
+	if err != nil {
+		respondTaskError(c, err)
+		return models.Attachment{}, false
+	}
+
+	return attachment, true
+}
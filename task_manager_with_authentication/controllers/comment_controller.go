@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/data"
+	"task_manager/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ----------------------------
+// LIST COMMENTS
+// GET /tasks/:id/comments
+// Anyone who can read the task sees its comments as threads
+// ----------------------------
+func GetTaskComments(c *gin.Context) {
+	task, ok := loadTask(c, accessRead)
+	if !ok {
+		return
+	}
+
+	comments, err := data.GetTaskComments(task.ID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": comments})
+}
+
+// ----------------------------
+// POST COMMENT
+// POST /tasks/:id/comments
+// Anyone who can read the task may comment; the author comes from the token
+// ----------------------------
+func CreateComment(c *gin.Context) {
+	task, ok := loadTask(c, accessRead)
+	if !ok {
+		return
+	}
+	userID, _ := currentUserID(c)
+
+	var input models.CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := data.CreateComment(task.ID, input, userID, c.GetString("username"))
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "comment added successfully",
+		"data":    comment,
+	})
+}
+
+// ----------------------------
+// EDIT COMMENT (AUTHOR OR ADMIN)
+// PATCH /tasks/:id/comments/:commentId
+// ----------------------------
+func UpdateComment(c *gin.Context) {
+	comment, ok := loadOwnComment(c)
+	if !ok {
+		return
+	}
+
+	var input models.CommentUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := data.UpdateComment(comment.TaskID, comment.ID, input.Body)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "comment updated successfully",
+		"data":    updated,
+	})
+}
+
+// ----------------------------
+// DELETE COMMENT (AUTHOR OR ADMIN)
+// DELETE /tasks/:id/comments/:commentId
+// ----------------------------
+func DeleteComment(c *gin.Context) {
+	comment, ok := loadOwnComment(c)
+	if !ok {
+		return
+	}
+
+	if err := data.DeleteComment(comment.TaskID, comment.ID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "comment deleted successfully"})
+}
+
+// loadOwnComment fetches the comment named by :commentId on a task the
+// caller can read, and checks that the caller wrote it or is an admin
+func loadOwnComment(c *gin.Context) (models.Comment, bool) {
+	task, ok := loadTask(c, accessRead)
+	if !ok {
+		return models.Comment{}, false
+	}
+	userID, _ := currentUserID(c)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment ID"})
+		return models.Comment{}, false
+	}
+
+	comment, err := data.GetCommentByID(task.ID, id)
+	if err != nil {
+		respondTaskError(c, err)
+		return models.Comment{}, false
+	}
+
+	if comment.AuthorID != userID && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author or an admin can change this comment"})
+		return models.Comment{}, false
+	}
+
+	return comment, true
+}
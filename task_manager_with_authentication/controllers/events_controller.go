@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"task_manager/data"
+)
+
+// keepAliveInterval is how often an idle stream gets a comment line
+// so proxies do not time it out
+const keepAliveInterval = 15 * time.Second
+
+// ----------------------------
+// STREAM TASK CHANGES
+// GET /tasks/events
+// ----------------------------
+// Streams task changes as Server-Sent Events until the client disconnects.
+// Users only see changes to tasks they can read; admins see every change
+// with ?all=true. A user who loses access to a task gets a task.deleted
+// event carrying only its ID. Clients resuming with a Last-Event-ID
+// header first get the retained events they missed.
+func StreamTasks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	all := isAdmin(c) && c.Query("all") == "true"
+
+	var after uint64
+	if s := c.GetHeader("Last-Event-ID"); s != "" {
+		var err error
+		if after, err = strconv.ParseUint(s, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID must be an event ID"})
+			return
+		}
+	}
+
+	events, cancel := data.SubscribeTaskEvents(after)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, send := eventFor(ev, userID, all)
+			if !send {
+				continue
+			}
+			body, err := json.Marshal(payload)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, payload["type"], body)
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// eventFor decides what, if anything, the user should see of an event
+func eventFor(ev data.TaskEvent, userID primitive.ObjectID, all bool) (gin.H, bool) {
+	if all || ev.Task.CanRead(userID) {
+		return gin.H{"type": ev.Type, "task": ev.Task, "at": ev.At}, true
+	}
+	for _, id := range ev.Revoked {
+		if id == userID {
+			return gin.H{"type": data.EventTaskDeleted, "task": gin.H{"id": ev.Task.ID}, "at": ev.At}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"task_manager/data"
+	"task_manager/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ----------------------------
+// GET ALL TASKS
+// GET /tasks
+// Users see tasks they own, are assigned to or have been shared;
+// admins can pass ?all=true to see everyone's
+// ----------------------------
+func GetAllTasks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var tasks []models.Task
+	var err error
+	if isAdmin(c) && c.Query("all") == "true" {
+		tasks, err = data.GetAllTasks()
+	} else {
+		tasks, err = data.GetTasksVisibleTo(userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tasks})
+}
+
+// ----------------------------
+// GET TASK BY ID
+// GET /tasks/:id
+// ----------------------------
+func GetTaskByID(c *gin.Context) {
+	task, ok := loadTask(c, accessRead)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": task})
+}
+
+// ----------------------------
+// CREATE TASK
+// POST /tasks
+// The caller becomes the task's owner
+// ----------------------------
+func CreateTask(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input models.TaskInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := data.CreateTask(input, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create task"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "task created successfully",
+		"data":    task,
+	})
+}
+
+// ----------------------------
+// UPDATE TASK (OWNER, ASSIGNEE, WRITE COLLABORATOR OR ADMIN)
+// PUT /tasks/:id
+// ----------------------------
+func UpdateTask(c *gin.Context) {
+	task, ok := loadTask(c, accessWrite)
+	if !ok {
+		return
+	}
+
+	var input models.TaskInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := data.UpdateTask(task.ID, input)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "task updated successfully",
+		"data":    updated,
+	})
+}
+
+// ----------------------------
+// DELETE TASK (OWNER OR ADMIN)
+// DELETE /tasks/:id
+// ----------------------------
+func DeleteTask(c *gin.Context) {
+	task, ok := loadTask(c, accessManage)
+	if !ok {
+		return
+	}
+
+	if err := data.DeleteTask(task.ID); err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "task deleted successfully"})
+}
+
+// ----------------------------
+// ASSIGN TASK (OWNER OR ADMIN)
+// POST /tasks/:id/assignees
+// ----------------------------
+func AssignTask(c *gin.Context) {
+	task, ok := loadTask(c, accessManage)
+	if !ok {
+		return
+	}
+
+	var input models.AssignInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := lookupUser(c, input.UserID)
+	if !ok {
+		return
+	}
+
+	updated, err := data.AssignTask(task.ID, userID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "task assigned successfully",
+		"data":    updated,
+	})
+}
+
+// ----------------------------
+// UNASSIGN TASK (OWNER OR ADMIN)
+// DELETE /tasks/:id/assignees/:userId
+// ----------------------------
+func UnassignTask(c *gin.Context) {
+	task, ok := loadTask(c, accessManage)
+	if !ok {
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	updated, err := data.UnassignTask(task.ID, userID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "task unassigned successfully",
+		"data":    updated,
+	})
+}
+
+// ----------------------------
+// SHARE TASK (OWNER OR ADMIN)
+// POST /tasks/:id/collaborators
+// ----------------------------
+func ShareTask(c *gin.Context) {
+	task, ok := loadTask(c, accessManage)
+	if !ok {
+		return
+	}
+
+	var input models.ShareInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := lookupUser(c, input.UserID)
+	if !ok {
+		return
+	}
+	if userID == task.OwnerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the owner already has full access"})
+		return
+	}
+
+	updated, err := data.ShareTask(task.ID, userID, input.Access)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "task shared successfully",
+		"data":    updated,
+	})
+}
+
+// ----------------------------
+// UNSHARE TASK (OWNER OR ADMIN)
+// DELETE /tasks/:id/collaborators/:userId
+// ----------------------------
+func UnshareTask(c *gin.Context) {
+	task, ok := loadTask(c, accessManage)
+	if !ok {
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	updated, err := data.UnshareTask(task.ID, userID)
+	if err != nil {
+		respondTaskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "task unshared successfully",
+		"data":    updated,
+	})
+}
+
+// taskAccess is the level of access a handler needs on a task
+type taskAccess int
+
+const (
+	accessRead   taskAccess = iota // owner, assignee or any collaborator
+	accessWrite                    // owner, assignee or write collaborator
+	accessManage                   // owner only (delete, assign, share)
+)
+
+// loadTask fetches the task named by :id and checks that the caller has
+// the required access (admins always do), writing the error response if not
+func loadTask(c *gin.Context, need taskAccess) (models.Task, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return models.Task{}, false
+	}
+
+	id, ok := parseTaskID(c)
+	if !ok {
+		return models.Task{}, false
+	}
+
+	task, err := data.GetTaskByID(id)
+	if err != nil {
+		respondTaskError(c, err)
+		return models.Task{}, false
+	}
+
+	if isAdmin(c) {
+		return task, true
+	}
+
+	var allowed bool
+	switch need {
+	case accessRead:
+		allowed = task.CanRead(userID)
+	case accessWrite:
+		allowed = task.CanWrite(userID)
+	case accessManage:
+		allowed = task.OwnerID == userID
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you do not have access to this task"})
+		return models.Task{}, false
+	}
+
+	return task, true
+}
+
+// lookupUser parses a user ID from a request body and checks the user
+// exists, responding with 400 or 404 if not
+func lookupUser(c *gin.Context, hex string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return primitive.NilObjectID, false
+	}
+
+	if _, err := data.GetUserByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return primitive.NilObjectID, false
+	}
+
+	return id, true
+}
+
+// currentUserID reads the caller's ID from the claims set by AuthRequired,
+// responding with 401 if it is missing or malformed
+func currentUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// isAdmin reports whether the caller has the admin role
+func isAdmin(c *gin.Context) bool {
+	return c.GetString("role") == "admin"
+}
+
+// parseTaskID reads the :id path parameter as an ObjectID,
+// responding with 400 if it is malformed
+func parseTaskID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// respondTaskError maps data layer errors to HTTP responses. Unexpected
+// errors are logged and answered with a generic message so database details
+// don't leak to clients.
+func respondTaskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrTaskNotFound), errors.Is(err, data.ErrCommentNotFound),
+		errors.Is(err, data.ErrAttachmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, data.ErrParentCommentNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, data.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     err.Error(),
+			"max_bytes": data.MaxAttachmentSize,
+		})
+	case errors.Is(err, data.ErrAttachmentType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":   err.Error(),
+			"allowed": data.AllowedAttachmentTypes,
+		})
+	default:
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,330 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"task_manager/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var AttachmentCollection *mongo.Collection
+
+// Attachments holds the contents of uploaded files, set by
+// InitAttachmentStorage
+var Attachments AttachmentStorage
+
+// MaxAttachmentSize is the largest file that can be uploaded, in bytes
+var MaxAttachmentSize int64 = 10 << 20
+
+// AllowedAttachmentTypes lists the media types that can be uploaded.
+// Types are detected from the file contents, not taken from the client.
+var AllowedAttachmentTypes = []string{
+	"application/pdf",
+	"application/zip",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"text/plain",
+}
+
+// ErrAttachmentNotFound is returned when no attachment matches the given ID
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// ErrAttachmentTooLarge is returned when an upload exceeds MaxAttachmentSize
+var ErrAttachmentTooLarge = errors.New("attachment is too large")
+
+// ErrAttachmentType is returned when an upload's type is not allowed
+var ErrAttachmentType = errors.New("attachment type is not allowed")
+
+// Initialize the attachment collection after DB connects
+func InitAttachmentCollection() {
+	AttachmentCollection = Client.Database(DatabaseName).Collection("attachments")
+}
+
+// -------------------------------
+// STORAGE
+// -------------------------------
+
+// AttachmentStorage keeps the contents of attachments by key.
+// Their metadata lives in AttachmentCollection.
+type AttachmentStorage interface {
+	// Save stores everything read from r under key and returns its size
+	Save(key string, r io.Reader) (int64, error)
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the contents under key; missing keys are not an error
+	Delete(key string) error
+}
+
+// DiskStorage is an AttachmentStorage keeping one file per key in Dir
+type DiskStorage struct {
+	Dir string
+}
+
+// Save writes to a temporary file first, so a failed upload never leaves
+// a partial file under key
+func (s DiskStorage) Save(key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(s.Dir, ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+
+	return n, os.Rename(tmp.Name(), path)
+}
+
+func (s DiskStorage) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s DiskStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key to its file, refusing keys that would leave Dir
+func (s DiskStorage) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.Dir, key), nil
+}
+
+// InitAttachmentStorage sets up disk storage for attachments.
+// Configuration comes from the environment:
+//
+//	ATTACHMENT_DIR        directory for uploaded files (default attachments)
+//	ATTACHMENT_MAX_BYTES  largest upload in bytes (default 10485760)
+//	ATTACHMENT_TYPES      comma-separated allowed media types
+//	                      (default AllowedAttachmentTypes)
+//
+// It exits the process if the configuration is invalid.
+func InitAttachmentStorage() {
+	dir := os.Getenv("ATTACHMENT_DIR")
+	if dir == "" {
+		dir = "attachments"
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		log.Fatalf("Failed to create attachment directory: %v", err)
+	}
+	Attachments = DiskStorage{Dir: dir}
+
+	if s := os.Getenv("ATTACHMENT_MAX_BYTES"); s != "" {
+		size, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || size <= 0 {
+			log.Fatalf("Invalid ATTACHMENT_MAX_BYTES %q", s)
+		}
+		MaxAttachmentSize = size
+	}
+
+	if s := os.Getenv("ATTACHMENT_TYPES"); s != "" {
+		AllowedAttachmentTypes = nil
+		for _, t := range strings.Split(s, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				AllowedAttachmentTypes = append(AllowedAttachmentTypes, t)
+			}
+		}
+	}
+}
+
+// -------------------------------
+// ATTACHMENT CRUD
+// -------------------------------
+
+// GetTaskAttachments returns the attachments of a task, oldest first
+func GetTaskAttachments(taskID primitive.ObjectID) ([]models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := AttachmentCollection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := []models.Attachment{}
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment finds an attachment of a task
+func GetAttachment(taskID, id primitive.ObjectID) (models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attachment models.Attachment
+	err := AttachmentCollection.FindOne(ctx, bson.M{"_id": id, "task_id": taskID}).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Attachment{}, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+// OpenAttachment opens the contents of an attachment for reading
+func OpenAttachment(attachment models.Attachment) (io.ReadCloser, error) {
+	return Attachments.Open(attachment.ID.Hex())
+}
+
+// SaveAttachment stores a file uploaded to a task. The file's type is
+// detected from its first bytes and must be in AllowedAttachmentTypes,
+// and it must not be larger than MaxAttachmentSize.
+func SaveAttachment(taskID primitive.ObjectID, filename string, r io.Reader, uploaderID primitive.ObjectID, uploader string) (models.Attachment, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return models.Attachment{}, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !isAllowedAttachmentType(mediaType) {
+		return models.Attachment{}, fmt.Errorf("%w: %s", ErrAttachmentType, mediaType)
+	}
+
+	attachment := models.Attachment{
+		ID:          primitive.NewObjectID(),
+		TaskID:      taskID,
+		UploaderID:  uploaderID,
+		Uploader:    uploader,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		CreatedAt:   time.Now().UTC(),
+	}
+	key := attachment.ID.Hex()
+
+	// read one byte past the limit to tell a full-size file from a larger one
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head), r), MaxAttachmentSize+1)
+	size, err := Attachments.Save(key, body)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if size > MaxAttachmentSize {
+		_ = Attachments.Delete(key)
+		return models.Attachment{}, ErrAttachmentTooLarge
+	}
+	attachment.Size = size
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := AttachmentCollection.InsertOne(ctx, attachment); err != nil {
+		_ = Attachments.Delete(key)
+		return models.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment removes an attachment and its contents
+func DeleteAttachment(taskID, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := AttachmentCollection.DeleteOne(ctx, bson.M{"_id": id, "task_id": taskID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrAttachmentNotFound
+	}
+
+	return Attachments.Delete(id.Hex())
+}
+
+// deleteTaskAttachments removes every attachment of a task
+func deleteTaskAttachments(ctx context.Context, taskID primitive.ObjectID) error {
+	cursor, err := AttachmentCollection.Find(ctx, bson.M{"task_id": taskID})
+	if err != nil {
+		return err
+	}
+
+	var attachments []models.Attachment
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		if err := Attachments.Delete(a.ID.Hex()); err != nil {
+			return err
+		}
+	}
+
+	_, err = AttachmentCollection.DeleteMany(ctx, bson.M{"task_id": taskID})
+	return err
+}
+
+// isAllowedAttachmentType reports whether mediaType is in AllowedAttachmentTypes
+func isAllowedAttachmentType(mediaType string) bool {
+	for _, t := range AllowedAttachmentTypes {
+		if t == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanFilename keeps the base name of an uploaded file, without control
+// characters and at most 255 bytes long
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if len(name) > 255 {
+		name = strings.ToValidUTF8(name[:255], "")
+	}
+	if name == "" || name == "." || name == ".." || name == "/" {
+		name = "attachment"
+	}
+	return name
+}
@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task_manager/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var CommentCollection *mongo.Collection
+
+// ErrCommentNotFound is returned when no live comment matches the given ID
+var ErrCommentNotFound = errors.New("comment not found")
+
+// ErrParentCommentNotFound is returned when replying to a comment that is
+// not on the task or has been deleted
+var ErrParentCommentNotFound = errors.New("parent comment not found")
+
+// Initialize the comment collection after DB connects
+func InitCommentCollection() {
+	CommentCollection = Client.Database(DatabaseName).Collection("comments")
+}
+
+// -------------------------------
+// COMMENT CRUD
+// -------------------------------
+
+// GetTaskComments returns the comments on a task as threads: top-level
+// comments, oldest first, each with its replies nested below it
+func GetTaskComments(taskID primitive.ObjectID) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := CommentCollection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	return buildThreads(comments), nil
+}
+
+// GetCommentByID finds a live comment on a task
+func GetCommentByID(taskID, id primitive.ObjectID) (models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var comment models.Comment
+	err := CommentCollection.FindOne(ctx, liveComment(taskID, id)).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Comment{}, ErrCommentNotFound
+	}
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	return comment, nil
+}
+
+// CreateComment adds a comment to a task, as a reply when input names
+// a parent comment
+func CreateComment(taskID primitive.ObjectID, input models.CommentInput, authorID primitive.ObjectID, author string) (models.Comment, error) {
+	comment := models.Comment{
+		ID:        primitive.NewObjectID(),
+		TaskID:    taskID,
+		AuthorID:  authorID,
+		Author:    author,
+		Body:      input.Body,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if input.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(input.ParentID)
+		if err != nil {
+			return models.Comment{}, ErrParentCommentNotFound
+		}
+		if _, err := GetCommentByID(taskID, parentID); err != nil {
+			if errors.Is(err, ErrCommentNotFound) {
+				return models.Comment{}, ErrParentCommentNotFound
+			}
+			return models.Comment{}, err
+		}
+		comment.ParentID = &parentID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := CommentCollection.InsertOne(ctx, comment); err != nil {
+		return models.Comment{}, err
+	}
+
+	return comment, nil
+}
+
+// UpdateComment replaces a comment's body and records when it was edited
+func UpdateComment(taskID, id primitive.ObjectID, body string) (models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"body": body, "edited_at": time.Now().UTC()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var comment models.Comment
+	err := CommentCollection.FindOneAndUpdate(ctx, liveComment(taskID, id), update, opts).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Comment{}, ErrCommentNotFound
+	}
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	return comment, nil
+}
+
+// DeleteComment removes a comment. A comment with replies is blanked and
+// marked deleted instead, so the thread below it stays readable.
+func DeleteComment(taskID, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	replies, err := CommentCollection.CountDocuments(ctx, bson.M{"parent_id": id})
+	if err != nil {
+		return err
+	}
+
+	if replies > 0 {
+		update := bson.M{
+			"$set":   bson.M{"deleted": true, "body": ""},
+			"$unset": bson.M{"edited_at": ""},
+		}
+		result, err := CommentCollection.UpdateOne(ctx, liveComment(taskID, id), update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrCommentNotFound
+		}
+		return nil
+	}
+
+	result, err := CommentCollection.DeleteOne(ctx, liveComment(taskID, id))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// deleteTaskComments removes every comment on a task
+func deleteTaskComments(ctx context.Context, taskID primitive.ObjectID) error {
+	_, err := CommentCollection.DeleteMany(ctx, bson.M{"task_id": taskID})
+	return err
+}
+
+// liveComment matches a comment on a task that has not been deleted
+func liveComment(taskID, id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id, "task_id": taskID, "deleted": bson.M{"$ne": true}}
+}
+
+// buildThreads nests replies under their parents, keeping the order of
+// comments. Deleted comments left without replies are dropped, and
+// replies whose parent is gone are shown at the top level.
+func buildThreads(comments []models.Comment) []models.Comment {
+	children := map[primitive.ObjectID][]int{}
+	known := map[primitive.ObjectID]bool{}
+	for _, c := range comments {
+		known[c.ID] = true
+	}
+
+	var roots []int
+	for i, c := range comments {
+		if c.ParentID != nil && known[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var build func(indexes []int) []models.Comment
+	build = func(indexes []int) []models.Comment {
+		threads := []models.Comment{}
+		for _, i := range indexes {
+			c := comments[i]
+			c.Replies = build(children[c.ID])
+			if c.Deleted && len(c.Replies) == 0 {
+				continue
+			}
+			threads = append(threads, c)
+		}
+		return threads
+	}
+
+	return build(roots)
+}
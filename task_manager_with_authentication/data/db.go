@@ -0,0 +1,70 @@
+package data
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Client is the shared MongoDB client, set by ConnectDB
+var Client *mongo.Client
+
+// DatabaseName is the database holding the users and tasks collections
+var DatabaseName = "task_manager_db"
+
+// -------------------------------
+// CONNECTION MANAGEMENT
+// -------------------------------
+
+// ConnectDB connects to MongoDB and verifies the connection.
+// Configuration comes from the environment:
+//
+//	MONGODB_URI  connection string (default mongodb://localhost:27017)
+//	MONGODB_DB   database name (default task_manager_db)
+//
+// It exits the process if the server cannot be reached.
+func ConnectDB() {
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+	if name := os.Getenv("MONGODB_DB"); name != "" {
+		DatabaseName = name
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		log.Fatalf("Failed to ping MongoDB: %v", err)
+	}
+
+	Client = client
+	log.Println("Connected to MongoDB")
+}
+
+// DisconnectDB closes the MongoDB connection, waiting up to 5 seconds
+// for in-flight operations to finish
+func DisconnectDB() {
+	if Client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := Client.Disconnect(ctx); err != nil {
+		log.Printf("Error disconnecting from MongoDB: %v", err)
+		return
+	}
+	log.Println("Disconnected from MongoDB")
+}
@@ -0,0 +1,123 @@
+package data
+
+import (
+	"sync"
+	"time"
+
+	"task_manager/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of task change published to event subscribers
+const (
+	EventTaskCreated = "task.created"
+	EventTaskUpdated = "task.updated"
+	EventTaskDeleted = "task.deleted"
+)
+
+// TaskEvent is a change to a task. ID increases by one per event so a
+// client can resume a stream after the last ID it saw.
+type TaskEvent struct {
+	ID   uint64      `json:"-"`
+	Type string      `json:"type"`
+	Task models.Task `json:"task"`
+	At   time.Time   `json:"at"`
+
+	// Revoked lists users the change took access away from,
+	// so their streams can tell them the task is gone
+	Revoked []primitive.ObjectID `json:"-"`
+}
+
+const (
+	eventBacklog     = 256 // events kept for clients resuming a stream
+	subscriberBuffer = 64  // events a subscriber may fall behind before it is dropped
+)
+
+var events = struct {
+	sync.Mutex
+	subs   map[chan TaskEvent]struct{}
+	recent []TaskEvent
+	lastID uint64
+	closed bool
+}{subs: make(map[chan TaskEvent]struct{})}
+
+// -------------------------------
+// PUBLISH & SUBSCRIBE
+// -------------------------------
+
+// publishTaskEvent sends a change to every subscriber. Subscribers that
+// have fallen too far behind are dropped (their channel is closed)
+// rather than blocking the request that made the change.
+func publishTaskEvent(typ string, task models.Task, revoked ...primitive.ObjectID) {
+	events.Lock()
+	defer events.Unlock()
+	if events.closed {
+		return
+	}
+
+	events.lastID++
+	ev := TaskEvent{ID: events.lastID, Type: typ, Task: task, At: time.Now().UTC(), Revoked: revoked}
+	events.recent = append(events.recent, ev)
+	if len(events.recent) > eventBacklog {
+		events.recent = events.recent[len(events.recent)-eventBacklog:]
+	}
+
+	for ch := range events.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(events.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// SubscribeTaskEvents returns a channel of every task change from now on,
+// preceded by the retained changes with an ID greater than after (0 for
+// none). Callers filter events by visibility. The channel is closed when
+// the subscriber falls behind, cancel is called or the server shuts down.
+func SubscribeTaskEvents(after uint64) (<-chan TaskEvent, func()) {
+	events.Lock()
+	defer events.Unlock()
+
+	var backlog []TaskEvent
+	if after > 0 {
+		for _, ev := range events.recent {
+			if ev.ID > after {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+
+	ch := make(chan TaskEvent, len(backlog)+subscriberBuffer)
+	for _, ev := range backlog {
+		ch <- ev
+	}
+	if events.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	events.subs[ch] = struct{}{}
+	return ch, func() {
+		events.Lock()
+		defer events.Unlock()
+		if _, ok := events.subs[ch]; ok {
+			delete(events.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// CloseTaskEvents ends every subscription so open streams do not hold up
+// server shutdown
+func CloseTaskEvents() {
+	events.Lock()
+	defer events.Unlock()
+	events.closed = true
+	for ch := range events.subs {
+		delete(events.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,241 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"task_manager/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var TaskCollection *mongo.Collection
+
+// ErrTaskNotFound is returned when no task matches the given ID
+var ErrTaskNotFound = errors.New("task not found")
+
+// Initialize the task collection after DB connects
+func InitTaskCollection() {
+	TaskCollection = Client.Database(DatabaseName).Collection("tasks")
+}
+
+// -------------------------------
+// TASK CRUD
+// -------------------------------
+
+// GetAllTasks returns every task
+func GetAllTasks() ([]models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := TaskCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// GetTasksVisibleTo returns the tasks a user owns, is assigned to
+// or has been shared with
+func GetTasksVisibleTo(userID primitive.ObjectID) ([]models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"owner_id": userID},
+			bson.M{"assignees": userID},
+			bson.M{"collaborators.user_id": userID},
+		},
+	}
+
+	cursor, err := TaskCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// GetTaskByID finds a task given its ObjectID
+func GetTaskByID(id primitive.ObjectID) (models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var task models.Task
+	err := TaskCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Task{}, ErrTaskNotFound
+	}
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	return task, nil
+}
+
+// CreateTask adds a new task owned by ownerID to MongoDB
+func CreateTask(input models.TaskInput, ownerID primitive.ObjectID) (models.Task, error) {
+	task := models.Task{
+		ID:            primitive.NewObjectID(),
+		Title:         input.Title,
+		Description:   input.Description,
+		DueDate:       input.DueDate,
+		Status:        input.Status,
+		OwnerID:       ownerID,
+		Assignees:     []primitive.ObjectID{},
+		Collaborators: []models.Collaborator{},
+	}
+	if task.Status == "" {
+		task.Status = "Pending"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := TaskCollection.InsertOne(ctx, task)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	publishTaskEvent(EventTaskCreated, task)
+	return task, nil
+}
+
+// UpdateTask replaces a task's fields with the input. The owner never changes.
+func UpdateTask(id primitive.ObjectID, input models.TaskInput) (models.Task, error) {
+	status := input.Status
+	if status == "" {
+		status = "Pending"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"title":       input.Title,
+			"description": input.Description,
+			"due_date":    input.DueDate,
+			"status":      status,
+		},
+	}
+
+	result, err := TaskCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.Task{}, ErrTaskNotFound
+	}
+
+	task, err := GetTaskByID(id)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	publishTaskEvent(EventTaskUpdated, task)
+	return task, nil
+}
+
+// DeleteTask removes a task with its comments and attachments
+func DeleteTask(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// find-and-delete so the event carries the task's members
+	var task models.Task
+	err := TaskCollection.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrTaskNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	publishTaskEvent(EventTaskDeleted, task)
+
+	// the task is gone either way, so clean-up failures are only logged
+	if err := deleteTaskComments(ctx, id); err != nil {
+		log.Printf("Failed to delete comments of task %s: %v", id.Hex(), err)
+	}
+	if err := deleteTaskAttachments(ctx, id); err != nil {
+		log.Printf("Failed to delete attachments of task %s: %v", id.Hex(), err)
+	}
+	return nil
+}
+
+// -------------------------------
+// ASSIGNMENT & SHARING
+// -------------------------------
+
+// AssignTask adds a user to the task's assignees (no-op if already assigned)
+func AssignTask(taskID, userID primitive.ObjectID) (models.Task, error) {
+	return updateTaskMembers(taskID, bson.M{"$addToSet": bson.M{"assignees": userID}})
+}
+
+// UnassignTask removes a user from the task's assignees
+func UnassignTask(taskID, userID primitive.ObjectID) (models.Task, error) {
+	return updateTaskMembers(taskID, bson.M{"$pull": bson.M{"assignees": userID}}, userID)
+}
+
+// ShareTask gives a user read or write access to the task,
+// replacing any access they already had
+func ShareTask(taskID, userID primitive.ObjectID, access string) (models.Task, error) {
+	// single pipeline update so the replace is atomic
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"collaborators": bson.M{"$concatArrays": bson.A{
+				bson.M{"$filter": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$collaborators", bson.A{}}},
+					"cond":  bson.M{"$ne": bson.A{"$$this.user_id", userID}},
+				}},
+				bson.A{bson.M{"user_id": userID, "access": access}},
+			}},
+		}},
+	}
+	return updateTaskMembers(taskID, update)
+}
+
+// UnshareTask removes a user's shared access to the task
+func UnshareTask(taskID, userID primitive.ObjectID) (models.Task, error) {
+	return updateTaskMembers(taskID, bson.M{"$pull": bson.M{"collaborators": bson.M{"user_id": userID}}}, userID)
+}
+
+// updateTaskMembers applies an update to a task and returns the result.
+// removed names a user the update may take access away from.
+func updateTaskMembers(taskID primitive.ObjectID, update interface{}, removed ...primitive.ObjectID) (models.Task, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var task models.Task
+	err := TaskCollection.FindOneAndUpdate(ctx, bson.M{"_id": taskID}, update, opts).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Task{}, ErrTaskNotFound
+	}
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	publishTaskEvent(EventTaskUpdated, task, removed...)
+	return task, nil
+}
@@ -17,7 +17,7 @@ var UserCollection *mongo.Collection
 
 // Initialize the user collection after DB connects
 func InitUserCollection() {
-	UserCollection = Client.Database("task_manager_db").Collection("users")
+	UserCollection = Client.Database(DatabaseName).Collection("users")
 }
 
 // -------------------------------
@@ -1,7 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"task_manager/data"
 	"task_manager/router"
@@ -9,14 +16,18 @@ import (
 
 func main() {
 
-
 	// -----------------------------
 	// Connect to MongoDB
 	// -----------------------------
 	data.ConnectDB()
+	defer data.DisconnectDB()
 
 	// Initialize collections
 	data.InitUserCollection()
+	data.InitTaskCollection()
+	data.InitCommentCollection()
+	data.InitAttachmentCollection()
+	data.InitAttachmentStorage()
 
 	log.Println("Database and collections initialized")
 
@@ -28,7 +39,31 @@ func main() {
 	// -----------------------------
 	// Start Server
 	// -----------------------------
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: r,
+	}
+	// end open event streams so they do not hold up shutdown
+	srv.RegisterOnShutdown(data.CloseTaskEvents)
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// -----------------------------
+	// Graceful Shutdown
+	// -----------------------------
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
 	}
 }
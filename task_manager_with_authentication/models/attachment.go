@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment describes a file uploaded to a task. The contents are kept
+// in attachment storage under the attachment's ID.
+type Attachment struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID      primitive.ObjectID `json:"task_id" bson:"task_id"`
+	UploaderID  primitive.ObjectID `json:"uploader_id" bson:"uploader_id"`
+	Uploader    string             `json:"uploader" bson:"uploader"` // username when the file was uploaded
+	Filename    string             `json:"filename" bson:"filename"`
+	ContentType string             `json:"content_type" bson:"content_type"` // detected from the contents
+	Size        int64              `json:"size" bson:"size"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Comment is a message on a task. Replies point at the comment they answer
+// through ParentID, which makes up the thread.
+type Comment struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID  `json:"task_id" bson:"task_id"`
+	ParentID  *primitive.ObjectID `json:"parent_id,omitempty" bson:"parent_id,omitempty"` // comment being replied to
+	AuthorID  primitive.ObjectID  `json:"author_id" bson:"author_id"`
+	Author    string              `json:"author" bson:"author"` // username when the comment was written
+	Body      string              `json:"body" bson:"body"`
+	Deleted   bool                `json:"deleted,omitempty" bson:"deleted,omitempty"` // removed, but kept because it has replies
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+	EditedAt  *time.Time          `json:"edited_at,omitempty" bson:"edited_at,omitempty"`
+	Replies   []Comment           `json:"replies,omitempty" bson:"-"` // filled in when listing threads
+}
+
+// Used when a comment is posted
+type CommentInput struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID string `json:"parent_id"` // optional; the comment to reply to
+}
+
+// Used when a comment is edited
+type CommentUpdateInput struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
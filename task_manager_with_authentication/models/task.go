@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Access levels a task can be shared with
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+// Task represents a task stored in MongoDB
+type Task struct {
+	ID            primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Title         string               `json:"title" bson:"title"`
+	Description   string               `json:"description" bson:"description"`
+	DueDate       time.Time            `json:"due_date" bson:"due_date"`
+	Status        string               `json:"status" bson:"status"`     // "Pending", "In Progress" or "Completed"
+	OwnerID       primitive.ObjectID   `json:"owner_id" bson:"owner_id"` // user who created the task
+	Assignees     []primitive.ObjectID `json:"assignees" bson:"assignees"`
+	Collaborators []Collaborator       `json:"collaborators" bson:"collaborators"`
+}
+
+// Collaborator is a user the task has been shared with
+type Collaborator struct {
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Access string             `json:"access" bson:"access"` // "read" or "write"
+}
+
+// IsAssignee reports whether the user is assigned to the task
+func (t Task) IsAssignee(userID primitive.ObjectID) bool {
+	for _, id := range t.Assignees {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanRead reports whether the user may view the task
+func (t Task) CanRead(userID primitive.ObjectID) bool {
+	if t.OwnerID == userID || t.IsAssignee(userID) {
+		return true
+	}
+	for _, c := range t.Collaborators {
+		if c.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWrite reports whether the user may modify the task:
+// its owner, an assignee or a collaborator with write access
+func (t Task) CanWrite(userID primitive.ObjectID) bool {
+	if t.OwnerID == userID || t.IsAssignee(userID) {
+		return true
+	}
+	for _, c := range t.Collaborators {
+		if c.UserID == userID && c.Access == AccessWrite {
+			return true
+		}
+	}
+	return false
+}
+
+// Used when a task is created or updated
+type TaskInput struct {
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description"`
+	DueDate     time.Time `json:"due_date" binding:"required"`
+	Status      string    `json:"status" binding:"omitempty,oneof=Pending 'In Progress' Completed"`
+}
+
+// Used when a task is assigned to a user
+type AssignInput struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// Used when a task is shared with a user
+type ShareInput struct {
+	UserID string `json:"user_id" binding:"required"`
+	Access string `json:"access" binding:"required,oneof=read write"`
+}
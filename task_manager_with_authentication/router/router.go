@@ -22,9 +22,33 @@ func SetupRouter() *gin.Engine {
 	authRoutes := r.Group("/")
 	authRoutes.Use(middleware.AuthRequired())
 
-	// READ-ONLY TASK ROUTES (USER + ADMIN)
+	// TASK ROUTES (USER + ADMIN)
+	// Owners, assignees and collaborators work on tasks according to their
+	// access (see controllers.loadTask); admins can access every task
 	authRoutes.GET("/tasks", controllers.GetAllTasks)
+	authRoutes.GET("/tasks/events", controllers.StreamTasks) // Server-Sent Events
 	authRoutes.GET("/tasks/:id", controllers.GetTaskByID)
+	authRoutes.POST("/tasks", controllers.CreateTask)
+	authRoutes.PUT("/tasks/:id", controllers.UpdateTask)
+	authRoutes.DELETE("/tasks/:id", controllers.DeleteTask)
+
+	// Assignment and sharing (owner or admin)
+	authRoutes.POST("/tasks/:id/assignees", controllers.AssignTask)
+	authRoutes.DELETE("/tasks/:id/assignees/:userId", controllers.UnassignTask)
+	authRoutes.POST("/tasks/:id/collaborators", controllers.ShareTask)
+	authRoutes.DELETE("/tasks/:id/collaborators/:userId", controllers.UnshareTask)
+
+	// Comments (readers comment; author or admin edits and deletes)
+	authRoutes.GET("/tasks/:id/comments", controllers.GetTaskComments)
+	authRoutes.POST("/tasks/:id/comments", controllers.CreateComment)
+	authRoutes.PATCH("/tasks/:id/comments/:commentId", controllers.UpdateComment)
+	authRoutes.DELETE("/tasks/:id/comments/:commentId", controllers.DeleteComment)
+
+	// Attachments (writers upload, readers download; uploader or admin deletes)
+	authRoutes.GET("/tasks/:id/attachments", controllers.GetTaskAttachments)
+	authRoutes.POST("/tasks/:id/attachments", controllers.UploadAttachment)
+	authRoutes.GET("/tasks/:id/attachments/:attachmentId", controllers.DownloadAttachment)
+	authRoutes.DELETE("/tasks/:id/attachments/:attachmentId", controllers.DeleteAttachment)
 
 	// -----------------------------
 	// ADMIN-ONLY ROUTES
@@ -32,11 +56,6 @@ func SetupRouter() *gin.Engine {
 	adminRoutes := authRoutes.Group("/")
 	adminRoutes.Use(middleware.AdminRequired())
 
-	// Admin manages tasks
-	adminRoutes.POST("/tasks", controllers.CreateTask)
-	adminRoutes.PUT("/tasks/:id", controllers.UpdateTask)
-	adminRoutes.DELETE("/tasks/:id", controllers.DeleteTask)
-
 	// Promote user to admin
 	adminRoutes.POST("/promote/:id", controllers.PromoteUser)
 